@@ -0,0 +1,469 @@
+// Package client talks to cryptzd over the websocket the CLI uses.
+// It authenticates with an API token, says HELLO, matches responses to operations, answers pings and reconnects when
+// the connection drops.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/golang/protobuf/proto"
+	"github.com/gorilla/websocket"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Version of the websocket protocol spoken by the client
+	ProtocolVersion = 2
+
+	// Time allowed to write a message to the server
+	writeWait = 10 * time.Second
+
+	// Time allowed between pings from the server before the connection is considered dead.
+	// The server pings every 54 seconds.
+	pingWait = 90 * time.Second
+)
+
+var (
+	ErrClosed       = errors.New("Client is closed.")
+	ErrDisconnected = errors.New("Connection to the server dropped before the operation completed. It may still complete on the server.")
+	ErrNoToken      = errors.New("An API token is required to connect. Request one for the key with POST /api/v1/tokens.")
+)
+
+// Config says where and how to connect
+type Config struct {
+	// URL of the server, e.g. wss://localhost:8000
+	URL string
+
+	// Fingerprint of the key to connect as
+	Fingerprint string
+
+	// API token issued to the key. Anyone can send a fingerprint, the token proves the client holds the private key.
+	Token string
+
+	// Sent to the server in the HELLO, e.g. "cryptz 1.4.0"
+	ClientVersion string
+
+	// Dialer used to connect, e.g. to trust a self-signed certificate. Defaults to websocket.DefaultDialer.
+	Dialer *websocket.Dialer
+
+	// Time to wait before the first attempt to reconnect. It doubles after every failed attempt up to MaxReconnectWait.
+	// Defaults to a second.
+	ReconnectWait time.Duration
+
+	// Defaults to 30 seconds
+	MaxReconnectWait time.Duration
+
+	// Events buffered before the client stops reading from the server. Defaults to 64.
+	EventBuffer int
+}
+
+// Error is an operation the server failed
+type Error struct {
+	Code    pb.Response_ErrorCode
+	Message string
+	Details []*pb.ErrorDetail
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Client performs operations on a cryptzd server. It is safe to use from several goroutines.
+type Client struct {
+	config Config
+
+	// Pushes from the server. Closed once the client is closed.
+	events chan *pb.Event
+
+	// Protects everything below
+	lock sync.Mutex
+	// Current connection, nil while reconnecting
+	ws *websocket.Conn
+	// Closed once ws is set
+	ready chan struct{}
+	// Operations waiting for a response, by opId
+	pending  map[int32]chan *pb.Response
+	nextOpId int32
+	// Projects subscribed to, subscribed to again after a reconnect
+	projectIds []int32
+	// What the server said in response to the last HELLO
+	hello  *pb.HelloResponse
+	closed bool
+
+	// One write at a time
+	writeLock sync.Mutex
+
+	// Closed by Close
+	done chan struct{}
+	// Closed once the connection loop has returned
+	stopped chan struct{}
+}
+
+// Dial connects to the server and says HELLO. The client keeps reconnecting until it is closed.
+func Dial(config Config) (*Client, error) {
+	if config.Token == "" {
+		return nil, ErrNoToken
+	}
+	if config.Dialer == nil {
+		config.Dialer = websocket.DefaultDialer
+	}
+	if config.ReconnectWait <= 0 {
+		config.ReconnectWait = time.Second
+	}
+	if config.MaxReconnectWait <= 0 {
+		config.MaxReconnectWait = 30 * time.Second
+	}
+	if config.EventBuffer <= 0 {
+		config.EventBuffer = 64
+	}
+
+	c := &Client{
+		config:  config,
+		events:  make(chan *pb.Event, config.EventBuffer),
+		ready:   make(chan struct{}),
+		pending: make(map[int32]chan *pb.Response),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	ws, err := c.connect()
+	if err != nil {
+		return nil, err
+	}
+	go c.run(ws)
+	return c, nil
+}
+
+// Events returns the events pushed by the server: messages, notifications and changes to subscribed projects.
+// Read them promptly, the client stops reading responses while the buffer is full.
+// Events with an eventId are pushed again after a reconnect until they are acknowledged with Ack.
+func (c *Client) Events() <-chan *pb.Event {
+	return c.events
+}
+
+// Hello returns what the server said in response to the last HELLO, including its capabilities
+func (c *Client) Hello() *pb.HelloResponse {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.hello
+}
+
+// Close closes the connection and stops reconnecting. Operations in flight fail with ErrDisconnected.
+func (c *Client) Close() error {
+	c.lock.Lock()
+	if c.closed {
+		c.lock.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.done)
+	ws := c.ws
+	c.lock.Unlock()
+
+	if ws != nil {
+		ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+		ws.Close()
+	}
+	<-c.stopped
+	return nil
+}
+
+// connect dials the server with the API token and says HELLO. The HELLO is answered before anything else is pushed.
+func (c *Client) connect() (*websocket.Conn, error) {
+	url := strings.TrimSuffix(c.config.URL, "/") + "/ws/" + c.config.Fingerprint
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+c.config.Token)
+	ws, resp, err := c.config.Dialer.Dial(url, header)
+	if err == websocket.ErrBadHandshake && resp != nil &&
+		(resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden) {
+		return nil, refused(resp)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	hello := &pb.Operation{
+		OpId: 1,
+		Hello: &pb.Hello{
+			ProtocolVersion: ProtocolVersion,
+			ClientVersion:   c.config.ClientVersion,
+		},
+	}
+	res, err := c.handshake(ws, hello)
+	if err != nil {
+		ws.Close()
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.hello = res.HelloResponse
+	c.lock.Unlock()
+	return ws, nil
+}
+
+func (c *Client) handshake(ws *websocket.Conn, hello *pb.Operation) (*pb.Response, error) {
+	if err := c.write(ws, hello); err != nil {
+		return nil, err
+	}
+	ws.SetReadDeadline(time.Now().Add(writeWait))
+	defer ws.SetReadDeadline(time.Time{})
+	for {
+		_, body, err := ws.ReadMessage()
+		if err != nil {
+			return nil, err
+		}
+		res := &pb.Response{}
+		if err := proto.Unmarshal(body, res); err != nil {
+			return nil, err
+		}
+		if res.OpId != hello.OpId {
+			continue
+		}
+		if res.Status != pb.Response_SUCCESS {
+			return nil, newError(res)
+		}
+		return res, nil
+	}
+}
+
+// run reads from the connection until it drops, then reconnects. It returns once the client is closed.
+func (c *Client) run(ws *websocket.Conn) {
+	defer func() {
+		close(c.events)
+		close(c.stopped)
+	}()
+
+	for ws != nil {
+		c.connected(ws)
+		c.read(ws)
+		c.disconnected()
+		ws = c.reconnect()
+	}
+}
+
+// connected makes ws the connection operations are written to
+func (c *Client) connected(ws *websocket.Conn) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ws = ws
+	close(c.ready)
+
+	// Closed while reconnecting, reading from ws returns right away
+	if c.closed {
+		ws.Close()
+		return
+	}
+
+	// Subscriptions don't outlive the connection. One that fails is tried again after the next reconnect.
+	if len(c.projectIds) > 0 {
+		projectIds := c.projectIds
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), writeWait)
+			defer cancel()
+			c.Subscribe(ctx, projectIds...)
+		}()
+	}
+}
+
+// disconnected fails the operations in flight and makes new ones wait for the next connection
+func (c *Client) disconnected() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.ws.Close()
+	c.ws = nil
+	c.ready = make(chan struct{})
+	for opId, ch := range c.pending {
+		close(ch)
+		delete(c.pending, opId)
+	}
+}
+
+// reconnect keeps trying to connect, backing off between attempts. It returns nil once the client is closed.
+func (c *Client) reconnect() *websocket.Conn {
+	wait := c.config.ReconnectWait
+	for {
+		select {
+		case <-c.done:
+			return nil
+		case <-time.After(wait):
+		}
+
+		ws, err := c.connect()
+		if err == nil {
+			return ws
+		}
+		// The server is never going to take this client
+		if e, ok := err.(*Error); ok && (e.Code == pb.Response_UPGRADE_REQUIRED || e.Code == pb.Response_UNAUTHENTICATED) {
+			go c.Close()
+			return nil
+		}
+
+		wait *= 2
+		if wait > c.config.MaxReconnectWait {
+			wait = c.config.MaxReconnectWait
+		}
+	}
+}
+
+// read hands responses to the operations waiting for them and events to Events until the connection drops
+func (c *Client) read(ws *websocket.Conn) {
+	ws.SetReadDeadline(time.Now().Add(pingWait))
+	ws.SetPingHandler(func(data string) error {
+		ws.SetReadDeadline(time.Now().Add(pingWait))
+		return ws.WriteControl(websocket.PongMessage, []byte(data), time.Now().Add(writeWait))
+	})
+
+	for {
+		messageType, body, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		if messageType != websocket.BinaryMessage {
+			continue
+		}
+
+		res := &pb.Response{}
+		if err := proto.Unmarshal(body, res); err != nil {
+			continue
+		}
+
+		if res.Event != nil {
+			select {
+			case c.events <- res.Event:
+			case <-c.done:
+				return
+			}
+			continue
+		}
+
+		c.lock.Lock()
+		ch, ok := c.pending[res.OpId]
+		delete(c.pending, res.OpId)
+		c.lock.Unlock()
+		if ok {
+			ch <- res
+		}
+	}
+}
+
+func (c *Client) write(ws *websocket.Conn, op *pb.Operation) error {
+	b, err := proto.Marshal(op)
+	if err != nil {
+		return err
+	}
+
+	c.writeLock.Lock()
+	defer c.writeLock.Unlock()
+
+	ws.SetWriteDeadline(time.Now().Add(writeWait))
+	return ws.WriteMessage(websocket.BinaryMessage, b)
+}
+
+// start waits for the client to be connected and gives op an opId. The response to op is sent on ch.
+// Both happen under the same lock, so an operation is never left waiting on a connection that already dropped.
+func (c *Client) start(ctx context.Context, op *pb.Operation, ch chan *pb.Response) (*websocket.Conn, error) {
+	for {
+		c.lock.Lock()
+		ws, ready, closed := c.ws, c.ready, c.closed
+		if ws != nil && !closed {
+			c.nextOpId++
+			op.OpId = c.nextOpId
+			c.pending[op.OpId] = ch
+		}
+		c.lock.Unlock()
+
+		if closed {
+			return nil, ErrClosed
+		}
+		if ws != nil {
+			return ws, nil
+		}
+		select {
+		case <-ready:
+		case <-c.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Do performs the operation and waits for its response. Operations that fail on the server return an *Error
+// along with the response. When ctx is done first, the operation is cancelled on the server.
+func (c *Client) Do(ctx context.Context, op *pb.Operation) (*pb.Response, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		if t := time.Until(deadline); t >= time.Second {
+			op.Timeout = int32(t / time.Second)
+		}
+	}
+
+	ch := make(chan *pb.Response, 1)
+	ws, err := c.start(ctx, op, ch)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.write(ws, op); err != nil {
+		c.forget(op.OpId)
+		return nil, err
+	}
+
+	select {
+	case res, ok := <-ch:
+		if !ok {
+			return nil, ErrDisconnected
+		}
+		if res.Status != pb.Response_SUCCESS {
+			return res, newError(res)
+		}
+		return res, nil
+
+	case <-ctx.Done():
+		c.forget(op.OpId)
+		// The response to the cancellation isn't waited for either
+		c.lock.Lock()
+		c.nextOpId++
+		cancelOp := &pb.Operation{OpId: c.nextOpId, CancelOpId: op.OpId}
+		c.lock.Unlock()
+		c.write(ws, cancelOp)
+		return nil, ctx.Err()
+	}
+}
+
+// forget stops waiting for the response to an operation
+func (c *Client) forget(opId int32) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.pending, opId)
+}
+
+// refused returns why the server turned the connection away: the token wasn't issued to the key, or the key can't be
+// used, e.g. it was never activated or has been revoked.
+func refused(res *http.Response) *Error {
+	body, _ := ioutil.ReadAll(res.Body)
+	e := &Error{
+		Code:    pb.Response_UNAUTHENTICATED,
+		Message: strings.TrimSpace(string(body)),
+	}
+	if e.Message == "" {
+		e.Message = fmt.Sprintf("Server refused the connection with %s", res.Status)
+	}
+	return e
+}
+
+func newError(res *pb.Response) *Error {
+	e := &Error{
+		Code:    res.ErrorCode,
+		Message: res.Error,
+		Details: res.ErrorDetails,
+	}
+	if e.Message == "" {
+		e.Message = fmt.Sprintf("Operation failed with %s", res.ErrorCode)
+	}
+	return e
+}
@@ -0,0 +1,361 @@
+package client
+
+import (
+	"context"
+	"database/sql"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"github.com/rajivnavada/cryptzd/web"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var startHub sync.Once
+
+// testServer is a cryptzd server running in the test, with a fresh database and the key in testdata imported
+type testServer struct {
+	*httptest.Server
+	dir         string
+	fingerprint string
+	token       string
+
+	// Connections to the server, so a test can drop them
+	lock  sync.Mutex
+	conns []net.Conn
+}
+
+func newTestServer(t *testing.T) *testServer {
+	dir, err := ioutil.TempDir("", "cryptzd-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &testServer{dir: dir}
+
+	// Keep the test key out of the keyring of whoever runs the tests
+	os.Setenv("GNUPGHOME", dir)
+	crypto.SqliteFilePath = filepath.Join(dir, "cryptzd.db")
+	schema, err := ioutil.ReadFile("../schema.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", crypto.SqliteFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = db.Exec(string(schema))
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	publicKey, err := ioutil.ReadFile("testdata/public_key.asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, _, err := crypto.ImportKeyAndUser(string(publicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.fingerprint = key.Fingerprint()
+
+	// Stands in for following the activation link in the email and decrypting a token from POST /api/v1/tokens
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbMap.Close()
+	key.Activate()
+	if err := key.Save(dbMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, s.token, err = crypto.NewAPIToken(key.Id(), dbMap); err != nil {
+		t.Fatal(err)
+	}
+
+	startHub.Do(func() { go web.H.Run() })
+
+	s.Server = httptest.NewUnstartedServer(web.Router())
+	s.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			s.lock.Lock()
+			s.conns = append(s.conns, conn)
+			s.lock.Unlock()
+		}
+	}
+	s.Start()
+	return s
+}
+
+// dropConnections closes every connection to the server, websockets included
+func (s *testServer) dropConnections() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, conn := range s.conns {
+		conn.Close()
+	}
+	s.conns = nil
+}
+
+func (s *testServer) close() {
+	s.Close()
+	os.RemoveAll(s.dir)
+}
+
+func (s *testServer) config(token string) Config {
+	return Config{
+		URL:           "ws" + strings.TrimPrefix(s.URL, "http"),
+		Fingerprint:   s.fingerprint,
+		Token:         token,
+		ClientVersion: "client test",
+		ReconnectWait: 10 * time.Millisecond,
+	}
+}
+
+func (s *testServer) dial(t *testing.T) *Client {
+	c, err := Dial(s.config(s.token))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func testContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 5*time.Second)
+}
+
+func TestClient(t *testing.T) {
+	s := newTestServer(t)
+	defer s.close()
+	c := s.dial(t)
+	defer c.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	if hello := c.Hello(); hello.ProtocolVersion != ProtocolVersion || len(hello.Capabilities) == 0 {
+		t.Fatalf("Unexpected HELLO response %v", hello)
+	}
+
+	p, err := c.CreateProject(ctx, "client", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.CreateProject(ctx, "client", "test"); err == nil || err.(*Error).Code != pb.Response_ALREADY_EXISTS {
+		t.Fatalf("Expected ALREADY_EXISTS creating the same project twice, got %v", err)
+	}
+
+	projects, err := c.ListProjects(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Id != p.Id {
+		t.Fatalf("Expected to find project %d, found %v", p.Id, projects)
+	}
+
+	if _, err := c.SetCredential(ctx, p.Id, "password", "hunter2"); err != nil {
+		t.Fatal(err)
+	}
+	cred, err := c.GetCredential(ctx, p.Id, "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cred.Key != "password" || cred.Cipher == "" || strings.Contains(cred.Cipher, "hunter2") {
+		t.Fatalf("Expected the credential encrypted to the key, got %v", cred)
+	}
+
+	creds, err := c.ListCredentials(ctx, p.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 1 {
+		t.Fatalf("Expected 1 credential, found %d", len(creds))
+	}
+
+	if err := c.DeleteCredential(ctx, p.Id, "password"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetCredential(ctx, p.Id, "password"); err == nil {
+		t.Fatal("Expected an error getting a deleted credential")
+	}
+}
+
+func TestClientAuthentication(t *testing.T) {
+	s := newTestServer(t)
+	defer s.close()
+
+	if _, err := Dial(s.config("")); err != ErrNoToken {
+		t.Fatalf("Expected ErrNoToken connecting without a token, got %v", err)
+	}
+	if _, err := Dial(s.config("not-a-token")); err == nil || err.(*Error).Code != pb.Response_UNAUTHENTICATED {
+		t.Fatalf("Expected UNAUTHENTICATED connecting with a token the server didn't issue, got %v", err)
+	}
+
+	// Revoking the key cuts off its tokens
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbMap.Close()
+	key, err := crypto.FindPublicKeyWithFingerprint(s.fingerprint, dbMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key.Revoke()
+	if err := key.Save(dbMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Dial(s.config(s.token)); err == nil || err.(*Error).Code != pb.Response_UNAUTHENTICATED {
+		t.Fatalf("Expected UNAUTHENTICATED connecting with an inactive key, got %v", err)
+	}
+}
+
+func TestClientOffboarded(t *testing.T) {
+	s := newTestServer(t)
+	defer s.close()
+
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbMap.Close()
+	key, err := crypto.FindPublicKeyWithFingerprint(s.fingerprint, dbMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := crypto.FindUserWithId(key.UserId(), dbMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Offboard(dbMap); err != nil {
+		t.Fatal(err)
+	}
+
+	// Signing in again with the same key must not bring it back
+	publicKey, err := ioutil.ReadFile("testdata/public_key.asc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := crypto.ImportKeyAndUser(string(publicKey)); err != crypto.RevokedKeyError {
+		t.Fatalf("Expected RevokedKeyError importing the key of an offboarded user, got %v", err)
+	}
+	if _, err := Dial(s.config(s.token)); err == nil || err.(*Error).Code != pb.Response_UNAUTHENTICATED {
+		t.Fatalf("Expected UNAUTHENTICATED connecting with the key of an offboarded user, got %v", err)
+	}
+
+	// Neither does reactivating the user
+	u.Reactivate()
+	if err := u.Save(dbMap); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := crypto.ImportKeyAndUser(string(publicKey)); err != crypto.RevokedKeyError {
+		t.Fatalf("Expected RevokedKeyError importing a revoked key of a reactivated user, got %v", err)
+	}
+	if key, err = crypto.FindPublicKeyWithFingerprint(s.fingerprint, dbMap); err != nil {
+		t.Fatal(err)
+	} else if !key.Revoked() {
+		t.Fatal("Expected the key to stay revoked")
+	}
+}
+
+func TestClientEvents(t *testing.T) {
+	s := newTestServer(t)
+	defer s.close()
+	c := s.dial(t)
+	defer c.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	p, err := c.CreateProject(ctx, "events", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Subscribe(ctx, p.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.SetCredential(ctx, p.Id, "token", "abc"); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case e := <-c.Events():
+		if e.Type != pb.Event_PROJECT || e.ProjectEvent.Type != pb.ProjectEvent_CREDENTIAL_SET || e.ProjectEvent.Key != "token" {
+			t.Fatalf("Unexpected event %v", e)
+		}
+	case <-ctx.Done():
+		t.Fatal("Timed out waiting for the project event")
+	}
+}
+
+func TestClientReconnect(t *testing.T) {
+	s := newTestServer(t)
+	defer s.close()
+	c := s.dial(t)
+	defer c.Close()
+
+	ctx, cancel := testContext()
+	defer cancel()
+
+	p, err := c.CreateProject(ctx, "reconnect", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Subscribe(ctx, p.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	s.dropConnections()
+
+	// Operations wait for the client to reconnect
+	projects, err := c.ListProjects(ctx)
+	for err == ErrDisconnected {
+		projects, err = c.ListProjects(ctx)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 {
+		t.Fatalf("Expected 1 project after reconnecting, found %d", len(projects))
+	}
+
+	// The subscription is made again once the client is back, wait for it before making a change
+	for {
+		if _, err := c.SetCredential(ctx, p.Id, "after", "reconnect"); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case e := <-c.Events():
+			if e.ProjectEvent == nil || e.ProjectEvent.Key != "after" {
+				t.Fatalf("Unexpected event %v", e)
+			}
+			return
+		case <-time.After(50 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("Timed out waiting for the project event after reconnecting")
+		}
+	}
+}
+
+func TestClientClose(t *testing.T) {
+	s := newTestServer(t)
+	defer s.close()
+	c := s.dial(t)
+
+	c.Close()
+	if _, ok := <-c.Events(); ok {
+		t.Fatal("Expected the events channel to be closed")
+	}
+	ctx, cancel := testContext()
+	defer cancel()
+	if _, err := c.ListProjects(ctx); err != ErrClosed {
+		t.Fatalf("Expected ErrClosed, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package client
+
+import (
+	"context"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+)
+
+// Credential ciphers and message bodies are returned the way the server stores them, encrypted to the key the client
+// connected as. Decrypting them is left to the caller.
+
+func (c *Client) project(ctx context.Context, op *pb.ProjectOperation) (*pb.ProjectOperationResponse, error) {
+	res, err := c.Do(ctx, &pb.Operation{ProjectOp: op})
+	if err != nil {
+		return nil, err
+	}
+	return res.ProjectOpResponse, nil
+}
+
+// ListProjects lists the projects the user is a member of
+func (c *Client) ListProjects(ctx context.Context) ([]*pb.Project, error) {
+	res, err := c.project(ctx, &pb.ProjectOperation{Command: pb.ProjectOperation_LIST})
+	if err != nil {
+		return nil, err
+	}
+	return res.Projects, nil
+}
+
+// CreateProject creates a project with the user as its admin
+func (c *Client) CreateProject(ctx context.Context, name, environment string) (*pb.Project, error) {
+	res, err := c.project(ctx, &pb.ProjectOperation{
+		Command:     pb.ProjectOperation_CREATE,
+		Name:        name,
+		Environment: environment,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Project, nil
+}
+
+// AddMember adds the user with the email address to the project, or changes their access level if they already are a member
+func (c *Client) AddMember(ctx context.Context, projectId int32, email, accessLevel string) error {
+	_, err := c.project(ctx, &pb.ProjectOperation{
+		Command:     pb.ProjectOperation_ADD_MEMBER,
+		ProjectId:   projectId,
+		MemberEmail: email,
+		AccessLevel: accessLevel,
+	})
+	return err
+}
+
+func (c *Client) DeleteMember(ctx context.Context, projectId, memberId int32) error {
+	_, err := c.project(ctx, &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_DELETE_MEMBER,
+		ProjectId: projectId,
+		MemberId:  memberId,
+	})
+	return err
+}
+
+// ListCredentials lists the credentials of the project without their values
+func (c *Client) ListCredentials(ctx context.Context, projectId int32) ([]*pb.Credential, error) {
+	res, err := c.project(ctx, &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_LIST_CREDENTIALS,
+		ProjectId: projectId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Credentials, nil
+}
+
+// GetCredential returns the credential with its value encrypted to the key of the client
+func (c *Client) GetCredential(ctx context.Context, projectId int32, key string) (*pb.Credential, error) {
+	res, err := c.project(ctx, &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_GET_CREDENTIAL,
+		ProjectId: projectId,
+		Key:       key,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Credential, nil
+}
+
+// SetCredential sets the value of the credential, which the server encrypts to every member of the project
+func (c *Client) SetCredential(ctx context.Context, projectId int32, key, value string) (*pb.Credential, error) {
+	res, err := c.project(ctx, &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_ADD_CREDENTIAL,
+		ProjectId: projectId,
+		Key:       key,
+		Value:     value,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.Credential, nil
+}
+
+func (c *Client) DeleteCredential(ctx context.Context, projectId int32, key string) error {
+	_, err := c.project(ctx, &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_DELETE_CREDENTIAL,
+		ProjectId: projectId,
+		Key:       key,
+	})
+	return err
+}
+
+// Subscribe replaces the projects the client receives events for. No projects unsubscribes.
+// The subscription is made again whenever the client reconnects.
+func (c *Client) Subscribe(ctx context.Context, projectIds ...int32) ([]*pb.Project, error) {
+	res, err := c.project(ctx, &pb.ProjectOperation{
+		Command:    pb.ProjectOperation_SUBSCRIBE,
+		ProjectIds: projectIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	c.projectIds = projectIds
+	c.lock.Unlock()
+	return res.Projects, nil
+}
+
+// ListMessages returns a page of the messages encrypted to the key of the client, newest first
+func (c *Client) ListMessages(ctx context.Context, page, perPage int32) (*pb.MessageOperationResponse, error) {
+	res, err := c.Do(ctx, &pb.Operation{MessageOp: &pb.MessageOperation{
+		Command: pb.MessageOperation_LIST,
+		Page:    page,
+		PerPage: perPage,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	return res.MessageOpResponse, nil
+}
+
+// Ack acknowledges every event up to and including eventId, so they aren't pushed again after a reconnect
+func (c *Client) Ack(ctx context.Context, eventId int32) error {
+	_, err := c.Do(ctx, &pb.Operation{EventOp: &pb.EventOperation{
+		Command: pb.EventOperation_ACK,
+		EventId: eventId,
+	}})
+	return err
+}
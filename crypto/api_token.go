@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+)
+
+const (
+	// Random bytes in a token, before hex encoding
+	API_TOKEN_LENGTH = 32
+)
+
+type apiTokenCore struct {
+	Id          int       `db:"id"`
+	PublicKeyId int       `db:"public_key_id"`
+	TokenHash   string    `db:"token_hash"`
+	CreatedAt   time.Time `db:"created_at"`
+	ExpiresAt   time.Time `db:"expires_at"`
+	LastUsedAt  time.Time `db:"last_used_at"`
+}
+
+// apiToken authenticates requests to the HTTP API as the key it was issued to.
+// Only a hash of the token is stored, the token itself is handed out once encrypted to the key.
+type apiToken struct {
+	*apiTokenCore
+}
+
+func (t apiToken) Id() int {
+	return t.apiTokenCore.Id
+}
+
+func (t apiToken) PublicKeyId() int {
+	return t.apiTokenCore.PublicKeyId
+}
+
+func (t apiToken) CreatedAt() time.Time {
+	return t.apiTokenCore.CreatedAt
+}
+
+func (t apiToken) ExpiresAt() time.Time {
+	return t.apiTokenCore.ExpiresAt
+}
+
+func (t apiToken) LastUsedAt() time.Time {
+	return t.apiTokenCore.LastUsedAt
+}
+
+// Touch records that the token was just used
+func (t *apiToken) Touch() {
+	t.apiTokenCore.LastUsedAt = time.Now().UTC()
+}
+
+func (t apiToken) Save(dbMap DataMapper) error {
+	if t.Id() > 0 {
+		_, err := dbMap.Update(t.apiTokenCore)
+		return err
+	}
+	return dbMap.Insert(t.apiTokenCore)
+}
+
+func (t apiToken) Delete(dbMap DataMapper) error {
+	_, err := dbMap.Delete(t.apiTokenCore)
+	return err
+}
+
+func hashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIToken issues a token for the key that expires after APITokenTTL. The token is only ever returned here.
+func NewAPIToken(publicKeyId int, dbMap DataMapper) (APIToken, string, error) {
+	b := make([]byte, API_TOKEN_LENGTH)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	token := hex.EncodeToString(b)
+
+	now := time.Now().UTC()
+	t := &apiToken{&apiTokenCore{
+		PublicKeyId: publicKeyId,
+		TokenHash:   hashAPIToken(token),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(APITokenTTL),
+		LastUsedAt:  now,
+	}}
+	if err := t.Save(dbMap); err != nil {
+		return nil, "", err
+	}
+	return t, token, nil
+}
+
+// FindAPIToken returns the unexpired token. Unknown and expired tokens are both APITokenNotFoundError.
+func FindAPIToken(token string, dbMap DataMapper) (APIToken, error) {
+	var tokens []*apiTokenCore
+	_, err := dbMap.Select(&tokens, "SELECT * FROM api_tokens WHERE token_hash = ? AND expires_at > ?", hashAPIToken(token), time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, APITokenNotFoundError
+	}
+	return &apiToken{tokens[0]}, nil
+}
+
+// PurgeExpiredAPITokens deletes tokens that can no longer be used. It returns the number of tokens deleted.
+func PurgeExpiredAPITokens(dbMap DataMapper) (int, error) {
+	res, err := dbMap.Exec("DELETE FROM api_tokens WHERE expires_at <= ?", time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
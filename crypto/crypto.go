@@ -3,14 +3,35 @@ package crypto
 import (
 	"errors"
 	"github.com/rajivnavada/gpgme"
+	"time"
 )
 
 var (
 	DebugMode                       = false
 	SqliteFilePath                  = ""
+	EncryptSubjects                 = false
+	MaxAttachmentSize               = int64(5 << 20)
+	MaxCredentialSize               = int64(1 << 20)
+	APITokenTTL                     = 30 * 24 * time.Hour
 	NotImplementedError             = errors.New("Not implemented")
 	InvalidArgumentsForMessageError = errors.New("Some or all of the arguments provided to message constructor are invalid.")
 	MisconfiguredKeyError           = errors.New("email address in key does not match email address of user in database.")
+	ServiceAccountLoginError        = errors.New("Service accounts cannot sign in. They are activated by a project admin.")
+	SuspendedUserLoginError         = errors.New("Suspended users cannot sign in. Please contact a server administrator.")
+	RevokedKeyError                 = errors.New("Key has been revoked. Sign in with a new key.")
+	ServiceAccountEmailInUseError   = errors.New("email address in key already belongs to a user that is not a service account.")
+	ServiceAccountReadOnlyError     = errors.New("Service accounts can only be granted read access to a project.")
+	ServiceAccountNotManagedError   = errors.New("Service account belongs to projects you are not an admin of.")
+	UserNotFoundError               = errors.New("User not found.")
+	NoActivePublicKeysError         = errors.New("User does not have any active public keys to encrypt to.")
+	NoProjectRecipientsError        = errors.New("Project does not have any members that can receive messages.")
+	MessageNotFoundError            = errors.New("Message not found.")
+	AttachmentNotFoundError         = errors.New("Attachment not found.")
+	AttachmentTooLargeError         = errors.New("Attachments are larger than the maximum allowed size.")
+	CredentialTooLargeError         = errors.New("Credential value is larger than the maximum allowed size.")
+	APITokenNotFoundError           = errors.New("API token is invalid or has expired.")
+	NestedTransactionError          = errors.New("A transaction is already in progress.")
+	LastProjectAdminError           = errors.New("User is the only admin of a project. Make someone else an admin of the project first.")
 )
 
 func ImportKeyAndUser(publicKey string) (PublicKey, User, error) {
@@ -31,14 +52,32 @@ func ImportKeyAndUser(publicKey string) (PublicKey, User, error) {
 	k, err = FindOrCreatePublicKeyWithFingerprint(ki.Fingerprint(), dbMap)
 	if err != nil {
 		return nil, nil, err
+	} else if k.Revoked() {
+		return nil, nil, RevokedKeyError
 	} else {
 		// Try to find the user attached to this key
 		u = k.User(dbMap)
-		if u == nil {
+		foundByEmail := u == nil
+		if foundByEmail {
 			u, err = FindOrCreateUserWithEmail(ki.Email(), dbMap)
 			if err != nil {
 				return nil, nil, err
 			}
+		} else if u.Email() != ki.Email() {
+			// If the key already belongs to a user, the email addresses must match
+			return nil, nil, MisconfiguredKeyError
+		}
+
+		// Service accounts are activated by project admins and never go through the email flow
+		if u.IsServiceAccount() {
+			return nil, nil, ServiceAccountLoginError
+		}
+		// Suspended users stay out until a server admin reactivates them
+		if u.IsSuspended() {
+			return nil, nil, SuspendedUserLoginError
+		}
+
+		if foundByEmail {
 			u.SetName(ki.Name())
 			u.SetComment(ki.Comment())
 
@@ -46,9 +85,6 @@ func ImportKeyAndUser(publicKey string) (PublicKey, User, error) {
 			if err != nil {
 				return nil, nil, err
 			}
-		} else if u.Email() != ki.Email() {
-			// If the key already belongs to a user, the email addresses must match
-			return nil, nil, MisconfiguredKeyError
 		}
 
 		// Now we can update some key info
@@ -65,6 +101,88 @@ func ImportKeyAndUser(publicKey string) (PublicKey, User, error) {
 	return k, u, nil
 }
 
+// ImportServiceAccount imports a key for a service account and activates it on behalf of adminUserId. A key can only
+// be added to an existing service account by someone who administers every project the account belongs to.
+func ImportServiceAccount(publicKey, name string, adminUserId int, dbMap DataMapper) (PublicKey, User, error) {
+	ki, err := gpgme.ImportPublicKey(publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k, err := FindOrCreatePublicKeyWithFingerprint(ki.Fingerprint(), dbMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	if k.Revoked() {
+		return nil, nil, RevokedKeyError
+	}
+
+	u, err := FindOrCreateUserWithEmail(ki.Email(), dbMap)
+	if err != nil {
+		return nil, nil, err
+	}
+	if u.Id() == 0 {
+		if name == "" {
+			name = ki.Name()
+		}
+		u = NewServiceAccount(ki.Email(), name, ki.Comment())
+		if err = u.Save(dbMap); err != nil {
+			return nil, nil, err
+		}
+	} else if !u.IsServiceAccount() {
+		return nil, nil, ServiceAccountEmailInUseError
+	} else {
+		// Credentials of every project the account belongs to are encrypted to the new key
+		projects, err := FindProjectsForUser(u.Id(), dbMap)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, p := range projects {
+			if !p.HasAdminWithUserId(adminUserId, dbMap) {
+				return nil, nil, ServiceAccountNotManagedError
+			}
+		}
+	}
+
+	// A key that is already attached to someone else cannot be reused for a service account
+	if k.UserId() != 0 && k.UserId() != u.Id() {
+		return nil, nil, MisconfiguredKeyError
+	}
+
+	// Service account keys are activated right away by the admin creating them
+	k.SetExpiresAt(ki.ExpiresAt())
+	k.SetUserId(u.Id())
+	k.SetKeyData([]byte(publicKey))
+	k.Activate()
+
+	if err = k.Save(dbMap); err != nil {
+		return nil, nil, err
+	}
+
+	return k, u, nil
+}
+
+func BootstrapAdmin(email string) (User, error) {
+	dbMap, err := NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	u, err := FindOrCreateUserWithEmail(email, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	// The user record is created ahead of time if needed, so the first key imported
+	// with this email address signs in as an admin
+	u.SetAdmin(true)
+	u.Reactivate()
+	if err = u.Save(dbMap); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
 //----------------------------------------
 // INIT
 //----------------------------------------
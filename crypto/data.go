@@ -26,9 +26,29 @@ type User interface {
 
 	ImageURL() string
 
+	UserType() string
+	IsServiceAccount() bool
+
+	IsAdmin() bool
+	SetAdmin(bool)
+
+	SuspendedAt() time.Time
+	IsSuspended() bool
+	Suspend()
+	Reactivate()
+
 	PublicKeys(dbMap DataMapper) ([]PublicKey, error)
 	ActivePublicKeys(dbMap DataMapper) ([]PublicKey, error)
-	EncryptAndSave(sender User, message, subject string, dbMap DataMapper) (map[string]EncryptedMessage, error)
+	EncryptAndSave(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error)
+	Delete(dbMap DataMapper) error
+
+	VaultCredentials(publicKeyId int, dbMap DataMapper) ([]UserCredential, error)
+	GetVaultCredential(key string, publicKeyId int, dbMap DataMapper) (UserCredential, error)
+	SetVaultCredential(key, value string, dbMap DataMapper) error
+	RemoveVaultCredential(key string, dbMap DataMapper) error
+
+	DecryptableCredentials(dbMap DataMapper) ([]ExposedCredential, error)
+	Offboard(dbMap DataMapper) ([]ExposedCredential, error)
 }
 
 type PublicKey interface {
@@ -50,24 +70,90 @@ type PublicKey interface {
 
 	ActivatedAt() time.Time
 	Active() bool
+	Expired() bool
+	Revoked() bool
 
 	Activate()
+	Revoke()
 	User(dbMap DataMapper) User
 	Messages(dbMap DataMapper) ([]EncryptedMessage, error)
+	FindMessages(filter MessageFilter, dbMap DataMapper) ([]EncryptedMessage, int, error)
+	Thread(threadId int, dbMap DataMapper) ([]EncryptedMessage, error)
+
+	LastEventId() int
+	UndeliveredEvents(dbMap DataMapper) ([]KeyEvent, error)
+	AckEvents(eventId int, dbMap DataMapper) error
 	Encrypt(string) (string, error)
-	EncryptAndSave(sender User, message, subject string, dbMap DataMapper) (EncryptedMessage, error)
+	EncryptAndSave(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (EncryptedMessage, error)
+	Delete(dbMap DataMapper) error
 }
 
 type EncryptedMessage interface {
 	Saveable
 
 	PublicKeyId() int
+	MessageGroupId() int
+	ParentId() int
+	ThreadId() int
 	Subject() string
+	SubjectEncrypted() bool
 	Cipher() []byte
 	CreatedAt() time.Time
 	UpdatedAt() time.Time
 
+	ReadAt() time.Time
+	IsRead() bool
+	MarkRead()
+	MarkUnread()
+
+	ExpiresAt() time.Time
+	ViewOnce() bool
+
 	Sender() User
+	Recipients() []User
+	Attachments() []MessageAttachment
+	Delete(dbMap DataMapper) error
+	Consume(dbMap DataMapper) error
+	Reply(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error)
+}
+
+type KeyEvent interface {
+	Saveable
+
+	PublicKeyId() int
+	MessageId() int
+	Text() string
+	CreatedAt() time.Time
+}
+
+type APIToken interface {
+	Saveable
+
+	PublicKeyId() int
+	CreatedAt() time.Time
+	ExpiresAt() time.Time
+	LastUsedAt() time.Time
+	Touch()
+	Delete(dbMap DataMapper) error
+}
+
+type HubBroadcast interface {
+	Saveable
+
+	Origin() string
+	Payload() []byte
+	CreatedAt() time.Time
+}
+
+type MessageAttachment interface {
+	Saveable
+
+	MessageId() int
+	Filename() string
+	ContentType() string
+	Size() int64
+	Cipher() []byte
+	CreatedAt() time.Time
 }
 
 type Project interface {
@@ -80,6 +166,8 @@ type Project interface {
 	UpdatedAt() time.Time
 
 	HasAdminWithUserId(userId int, dbMap DataMapper) bool
+	HasAdminOtherThanUserId(userId int, dbMap DataMapper) bool
+	HasMemberWithUserId(userId int, dbMap DataMapper) bool
 
 	Members(dbMap DataMapper) ([]ProjectMember, error)
 	AddMember(userId int, accessLevel string, dbMap DataMapper) (ProjectMember, error)
@@ -89,6 +177,9 @@ type Project interface {
 	GetCredential(key string, publicKeyId int, dbMap DataMapper) (ProjectCredentialValue, error)
 	SetCredential(key, value string, dbMap DataMapper) (ProjectCredentialKey, error)
 	RemoveCredential(key string, dbMap DataMapper) error
+
+	ServiceAccountUsage(dbMap DataMapper) ([]ServiceAccountUsage, error)
+	EncryptAndSave(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error)
 }
 
 type ProjectMember interface {
@@ -97,6 +188,7 @@ type ProjectMember interface {
 	ProjectId() int
 	UserId() int
 	AccessLevel() string
+	SetAccessLevel(accessLevel string)
 	CreatedAt() time.Time
 	UpdatedAt() time.Time
 
@@ -124,19 +216,42 @@ type ProjectCredentialValue interface {
 	PublicKeyId() int
 
 	Cipher() []byte
-	SetCipher([]byte)
+	Binary() bool
+	SetCipher([]byte, bool)
 
 	CreatedAt() time.Time
 	UpdatedAt() time.Time
 	ExpiresAt() time.Time
 }
 
+type ExposedCredential interface {
+	ProjectId() int
+	ProjectName() string
+	Environment() string
+	Key() string
+}
+
+type ServiceAccountUsage interface {
+	UserId() int
+	Email() string
+	Key() string
+	Count() int
+	LastAccessedAt() time.Time
+}
+
 type UserCredential interface {
 	Saveable
 
 	UserId() int
+	PublicKeyId() int
 	Key() string
+
 	Cipher() []byte
+	Binary() bool
+	SetCipher([]byte, bool)
+
 	CreatedAt() time.Time
 	UpdatedAt() time.Time
+
+	Delete(dbMap DataMapper) error
 }
@@ -1,19 +1,37 @@
 package crypto
 
 import (
+	"database/sql"
+	"strings"
 	"time"
 )
 
+const (
+	DEFAULT_MESSAGES_PER_PAGE = 25
+	MAX_MESSAGES_PER_PAGE     = 100
+
+	ENCRYPTED_SUBJECT_PLACEHOLDER = "Encrypted subject"
+)
+
 type encryptedMessageCore struct {
-	Id          int       `db:"id"`
-	SenderId    int       `db:"sender_id"`
-	PublicKeyId int       `db:"public_key_id"`
-	Subject     string    `db:"subject"`
-	Cipher      []byte    `db:"cipher"`
-	CreatedAt   time.Time `db:"created_at"`
-	UpdatedAt   time.Time `db:"updated_at"`
+	Id               int       `db:"id"`
+	SenderId         int       `db:"sender_id"`
+	PublicKeyId      int       `db:"public_key_id"`
+	MessageGroupId   int       `db:"message_group_id"`
+	ParentId         int       `db:"parent_id"`
+	ThreadId         int       `db:"thread_id"`
+	Subject          string    `db:"subject"`
+	Cipher           []byte    `db:"cipher"`
+	ReadAt           time.Time `db:"read_at"`
+	ExpiresAt        time.Time `db:"expires_at"`
+	ViewOnce         bool      `db:"view_once"`
+	SubjectEncrypted bool      `db:"subject_encrypted"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
 
-	sender User `db:"-"`
+	sender      User                `db:"-"`
+	recipients  []User              `db:"-"`
+	attachments []MessageAttachment `db:"-"`
 }
 
 func (e *encryptedMessageCore) loadSender(dbMap DataMapper) error {
@@ -26,6 +44,29 @@ func (e *encryptedMessageCore) loadSender(dbMap DataMapper) error {
 	return nil
 }
 
+func (e *encryptedMessageCore) loadRecipients(dbMap DataMapper) error {
+	if e.MessageGroupId == 0 {
+		return nil
+	}
+	users, err := findMessageGroupRecipients(e.MessageGroupId, dbMap)
+	if err != nil {
+		return err
+	}
+
+	e.recipients = users
+	return nil
+}
+
+func (e *encryptedMessageCore) loadAttachments(dbMap DataMapper) error {
+	attachments, err := findAttachmentsForMessage(e.Id, dbMap)
+	if err != nil {
+		return err
+	}
+
+	e.attachments = attachments
+	return nil
+}
+
 type encryptedMessage struct {
 	*encryptedMessageCore
 }
@@ -38,14 +79,61 @@ func (em encryptedMessage) PublicKeyId() int {
 	return em.encryptedMessageCore.PublicKeyId
 }
 
+func (em encryptedMessage) MessageGroupId() int {
+	return em.encryptedMessageCore.MessageGroupId
+}
+
+func (em encryptedMessage) ParentId() int {
+	return em.encryptedMessageCore.ParentId
+}
+
+// ThreadId is the id of the message that started the conversation. Messages that are not replies start their own thread.
+func (em encryptedMessage) ThreadId() int {
+	if em.encryptedMessageCore.ThreadId == 0 {
+		return em.Id()
+	}
+	return em.encryptedMessageCore.ThreadId
+}
+
 func (em encryptedMessage) Subject() string {
 	return em.encryptedMessageCore.Subject
 }
 
+// SubjectEncrypted reports if the real subject is inside the cipher. Subject() returns a placeholder in that case.
+func (em encryptedMessage) SubjectEncrypted() bool {
+	return em.encryptedMessageCore.SubjectEncrypted
+}
+
 func (em encryptedMessage) Cipher() []byte {
 	return em.encryptedMessageCore.Cipher
 }
 
+func (em encryptedMessage) ReadAt() time.Time {
+	return em.encryptedMessageCore.ReadAt
+}
+
+func (em encryptedMessage) IsRead() bool {
+	return !em.encryptedMessageCore.ReadAt.IsZero()
+}
+
+func (em *encryptedMessage) MarkRead() {
+	if em.encryptedMessageCore.ReadAt.IsZero() {
+		em.encryptedMessageCore.ReadAt = time.Now().UTC()
+	}
+}
+
+func (em *encryptedMessage) MarkUnread() {
+	em.encryptedMessageCore.ReadAt = time.Time{}
+}
+
+func (em encryptedMessage) ExpiresAt() time.Time {
+	return em.encryptedMessageCore.ExpiresAt
+}
+
+func (em encryptedMessage) ViewOnce() bool {
+	return em.encryptedMessageCore.ViewOnce
+}
+
 func (em encryptedMessage) CreatedAt() time.Time {
 	return em.encryptedMessageCore.CreatedAt
 }
@@ -61,6 +149,16 @@ func (em *encryptedMessage) Sender() User {
 	return em.encryptedMessageCore.sender
 }
 
+// Recipients lists everyone a group message was sent to. It is empty for messages sent to a single user.
+func (em *encryptedMessage) Recipients() []User {
+	return em.encryptedMessageCore.recipients
+}
+
+// Attachments lists the files sent with the message. Their ciphers are not loaded, use FindAttachmentForPublicKey to fetch one.
+func (em *encryptedMessage) Attachments() []MessageAttachment {
+	return em.encryptedMessageCore.attachments
+}
+
 func (em encryptedMessage) Save(dbMap DataMapper) error {
 	if em.Id() > 0 {
 		_, err := dbMap.Update(em.encryptedMessageCore)
@@ -69,17 +167,200 @@ func (em encryptedMessage) Save(dbMap DataMapper) error {
 	return dbMap.Insert(em.encryptedMessageCore)
 }
 
-func newMessage(publicKeyId, senderId int, cipher []byte, subject string) (*encryptedMessage, error) {
-	if len(cipher) == 0 || publicKeyId == 0 || senderId == 0 {
+func (em encryptedMessage) Delete(dbMap DataMapper) error {
+	_, err := dbMap.Delete(em.encryptedMessageCore)
+	return err
+}
+
+// Consume removes a view once message after the recipient has fetched it. The ciphers for the recipient's other keys go with it.
+func (em encryptedMessage) Consume(dbMap DataMapper) error {
+	if em.MessageGroupId() == 0 {
+		return em.Delete(dbMap)
+	}
+	var messages []*encryptedMessageCore
+	_, err := dbMap.Select(&messages, "SELECT * FROM encrypted_messages WHERE message_group_id = ? AND public_key_id IN (SELECT id FROM public_keys WHERE user_id = (SELECT user_id FROM public_keys WHERE id = ?))",
+		em.MessageGroupId(), em.PublicKeyId())
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if _, err = dbMap.Delete(m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reply sends a message back to the sender of this message. The reply joins the thread of this message.
+func (em *encryptedMessage) Reply(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+	to := em.Sender()
+	if to == nil {
+		return nil, UserNotFoundError
+	}
+	opts.parentId = em.Id()
+	opts.threadId = em.ThreadId()
+	// Replies are always grouped so the sender sees a single entry for them in the thread
+	return encryptAndSaveForGroup(sender, []User{to}, 0, message, subject, opts, dbMap)
+}
+
+// MessageOptions control how long a message is kept around after it is sent
+type MessageOptions struct {
+	// TTL is how long the message is kept before the purger deletes it. Zero keeps the message until it is deleted.
+	TTL time.Duration
+	// ViewOnce messages are deleted as soon as the recipient fetches them
+	ViewOnce bool
+	// EncryptSubject moves the subject into the encrypted envelope. EncryptSubjects turns this on for every message.
+	EncryptSubject bool
+	// Attachments are encrypted to the same keys as the message
+	Attachments []AttachmentFile
+
+	// parentId and threadId are set by Reply
+	parentId int
+	threadId int
+}
+
+// validate checks the options before anything is encrypted
+func (opts MessageOptions) validate() error {
+	if opts.TTL < 0 {
+		return InvalidArgumentsForMessageError
+	}
+	var size int64
+	for _, f := range opts.Attachments {
+		if f.Filename == "" {
+			return InvalidArgumentsForMessageError
+		}
+		size += int64(len(f.Data))
+	}
+	if size > MaxAttachmentSize {
+		return AttachmentTooLargeError
+	}
+	return nil
+}
+
+func (opts MessageOptions) encryptsSubject() bool {
+	return opts.EncryptSubject || EncryptSubjects
+}
+
+// envelope returns the text to encrypt and the subject to store in plaintext.
+// When the subject is encrypted the text is a header block followed by a blank line and the message:
+//
+//	Subject: new prod DB password
+//
+//	hunter2
+func (opts MessageOptions) envelope(message, subject string) (string, string) {
+	if !opts.encryptsSubject() {
+		return message, subject
+	}
+	// Keep the header to a single line so the envelope can always be parsed
+	subject = strings.Replace(strings.Replace(subject, "\r", " ", -1), "\n", " ", -1)
+	return "Subject: " + subject + "\n\n" + message, ENCRYPTED_SUBJECT_PLACEHOLDER
+}
+
+// MessageFilter narrows down the messages returned by PublicKey.FindMessages. Zero values are ignored.
+type MessageFilter struct {
+	SenderEmail string
+	Subject     string
+	Since       time.Time
+	Until       time.Time
+	UnreadOnly  bool
+	Page        int
+	PerPage     int
+}
+
+// whereClause returns the conditions and arguments of the filter to be appended to a query on encrypted_messages m joined with the sender u
+func (f MessageFilter) whereClause() (string, []interface{}) {
+	var where string
+	var args []interface{}
+	if f.SenderEmail != "" {
+		where += " AND u.email = ?"
+		args = append(args, f.SenderEmail)
+	}
+	if f.Subject != "" {
+		where += " AND m.subject LIKE ?"
+		args = append(args, "%"+f.Subject+"%")
+	}
+	if !f.Since.IsZero() {
+		where += " AND m.created_at >= ?"
+		args = append(args, f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		where += " AND m.created_at < ?"
+		args = append(args, f.Until.UTC())
+	}
+	// Expired messages are hidden even if the purger has not gotten to them yet
+	where += " AND (m.expires_at IS NULL OR m.expires_at = ? OR m.expires_at > ?)"
+	args = append(args, time.Time{}, time.Now().UTC())
+	if f.UnreadOnly {
+		where += " AND (m.read_at IS NULL OR m.read_at = ?)"
+		args = append(args, time.Time{})
+	}
+	return where, args
+}
+
+func (f MessageFilter) limits() (int, int) {
+	page := f.Page
+	if page < 1 {
+		page = 1
+	}
+	perPage := f.PerPage
+	if perPage < 1 || perPage > MAX_MESSAGES_PER_PAGE {
+		perPage = DEFAULT_MESSAGES_PER_PAGE
+	}
+	return perPage, (page - 1) * perPage
+}
+
+func FindMessageForPublicKey(id, publicKeyId int, dbMap DataMapper) (EncryptedMessage, error) {
+	mc := &encryptedMessageCore{}
+	err := dbMap.SelectOne(mc, "SELECT * FROM encrypted_messages WHERE id = ? AND public_key_id = ? AND (expires_at IS NULL OR expires_at = ? OR expires_at > ?)",
+		id, publicKeyId, time.Time{}, time.Now().UTC())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, MessageNotFoundError
+		}
+		return nil, err
+	}
+	if err = mc.loadSender(dbMap); err != nil {
+		return nil, err
+	}
+	if err = mc.loadRecipients(dbMap); err != nil {
+		return nil, err
+	}
+	if err = mc.loadAttachments(dbMap); err != nil {
+		return nil, err
+	}
+	return &encryptedMessage{mc}, nil
+}
+
+// PurgeExpiredMessages deletes every message whose time to live has run out and returns the number of messages deleted
+func PurgeExpiredMessages(dbMap DataMapper) (int, error) {
+	res, err := dbMap.Exec("DELETE FROM encrypted_messages WHERE expires_at IS NOT NULL AND expires_at != ? AND expires_at <= ?", time.Time{}, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+func newMessage(publicKeyId, senderId, messageGroupId int, cipher []byte, subject string, opts MessageOptions) (*encryptedMessage, error) {
+	if len(cipher) == 0 || publicKeyId == 0 || senderId == 0 || opts.TTL < 0 {
 		return nil, InvalidArgumentsForMessageError
 	}
 	currentTime := time.Now().UTC()
-	return &encryptedMessage{&encryptedMessageCore{
-		PublicKeyId: publicKeyId,
-		SenderId:    senderId,
-		Subject:     subject,
-		Cipher:      cipher,
-		CreatedAt:   currentTime,
-		UpdatedAt:   currentTime,
-	}}, nil
+	mc := &encryptedMessageCore{
+		PublicKeyId:      publicKeyId,
+		SenderId:         senderId,
+		MessageGroupId:   messageGroupId,
+		ParentId:         opts.parentId,
+		ThreadId:         opts.threadId,
+		Subject:          subject,
+		Cipher:           cipher,
+		ViewOnce:         opts.ViewOnce,
+		SubjectEncrypted: opts.encryptsSubject(),
+		CreatedAt:        currentTime,
+		UpdatedAt:        currentTime,
+	}
+	if opts.TTL > 0 {
+		mc.ExpiresAt = currentTime.Add(opts.TTL)
+	}
+	return &encryptedMessage{mc}, nil
 }
@@ -0,0 +1,29 @@
+package crypto
+
+type exposedCredentialCore struct {
+	ProjectId   int    `db:"project_id"`
+	ProjectName string `db:"project_name"`
+	Environment string `db:"environment"`
+	Key         string `db:"key"`
+}
+
+// exposedCredential is a credential a user was able to decrypt. Owners should rotate these when the user leaves.
+type exposedCredential struct {
+	*exposedCredentialCore
+}
+
+func (ec exposedCredential) ProjectId() int {
+	return ec.exposedCredentialCore.ProjectId
+}
+
+func (ec exposedCredential) ProjectName() string {
+	return ec.exposedCredentialCore.ProjectName
+}
+
+func (ec exposedCredential) Environment() string {
+	return ec.exposedCredentialCore.Environment
+}
+
+func (ec exposedCredential) Key() string {
+	return ec.exposedCredentialCore.Key
+}
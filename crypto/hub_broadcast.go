@@ -0,0 +1,90 @@
+package crypto
+
+import (
+	"time"
+)
+
+const (
+	// Instances that fall further behind than this miss the broadcasts in between
+	HUB_BROADCAST_RETENTION = 10 * time.Minute
+)
+
+type hubBroadcastCore struct {
+	Id        int       `db:"id"`
+	Origin    string    `db:"origin"`
+	Payload   []byte    `db:"payload"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// hubBroadcast is a websocket push made on one cryptzd instance that every other instance delivers to its own connections
+type hubBroadcast struct {
+	*hubBroadcastCore
+}
+
+func (hb hubBroadcast) Id() int {
+	return hb.hubBroadcastCore.Id
+}
+
+// Origin identifies the instance that made the broadcast
+func (hb hubBroadcast) Origin() string {
+	return hb.hubBroadcastCore.Origin
+}
+
+func (hb hubBroadcast) Payload() []byte {
+	return hb.hubBroadcastCore.Payload
+}
+
+func (hb hubBroadcast) CreatedAt() time.Time {
+	return hb.hubBroadcastCore.CreatedAt
+}
+
+func (hb hubBroadcast) Save(dbMap DataMapper) error {
+	if hb.Id() > 0 {
+		_, err := dbMap.Update(hb.hubBroadcastCore)
+		return err
+	}
+	return dbMap.Insert(hb.hubBroadcastCore)
+}
+
+func NewHubBroadcast(origin string, payload []byte, dbMap DataMapper) (HubBroadcast, error) {
+	hb := &hubBroadcast{&hubBroadcastCore{
+		Origin:    origin,
+		Payload:   payload,
+		CreatedAt: time.Now().UTC(),
+	}}
+	if err := hb.Save(dbMap); err != nil {
+		return nil, err
+	}
+	return hb, nil
+}
+
+// FindHubBroadcastsAfter returns the broadcasts of other instances made after the broadcast with id lastId, oldest first
+func FindHubBroadcastsAfter(lastId int, origin string, dbMap DataMapper) ([]HubBroadcast, error) {
+	var ret []HubBroadcast
+	var broadcasts []*hubBroadcastCore
+	_, err := dbMap.Select(&broadcasts, "SELECT * FROM hub_broadcasts WHERE id > ? AND origin != ? ORDER BY id ASC", lastId, origin)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range broadcasts {
+		ret = append(ret, &hubBroadcast{b})
+	}
+	return ret, nil
+}
+
+// LastHubBroadcastId is where an instance starts reading broadcasts from when it comes up
+func LastHubBroadcastId(dbMap DataMapper) (int, error) {
+	var id int
+	err := dbMap.SelectOne(&id, "SELECT IFNULL(MAX(id), 0) FROM hub_broadcasts")
+	return id, err
+}
+
+// PurgeHubBroadcasts deletes broadcasts every instance has had time to deliver. It returns the number of broadcasts deleted.
+func PurgeHubBroadcasts(dbMap DataMapper) (int, error) {
+	res, err := dbMap.Exec("DELETE FROM hub_broadcasts WHERE created_at <= ?", time.Now().UTC().Add(-HUB_BROADCAST_RETENTION))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,121 @@
+package crypto
+
+import (
+	"time"
+)
+
+const (
+	// Events that were never acknowledged, e.g. for keys only used in the browser, are dropped after this long
+	EVENT_RETENTION = 30 * 24 * time.Hour
+)
+
+type keyEventCore struct {
+	Id          int       `db:"id"`
+	PublicKeyId int       `db:"public_key_id"`
+	MessageId   int       `db:"message_id"`
+	Text        string    `db:"text"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// keyEvent is something pushed to a key: a new message or a notification.
+// Events stay queued until the key acknowledges them so they can be replayed when the key reconnects.
+type keyEvent struct {
+	*keyEventCore
+}
+
+func (e keyEvent) Id() int {
+	return e.keyEventCore.Id
+}
+
+func (e keyEvent) PublicKeyId() int {
+	return e.keyEventCore.PublicKeyId
+}
+
+// MessageId is the message delivered by the event. It is zero for notifications.
+func (e keyEvent) MessageId() int {
+	return e.keyEventCore.MessageId
+}
+
+// Text is the text of a notification
+func (e keyEvent) Text() string {
+	return e.keyEventCore.Text
+}
+
+func (e keyEvent) CreatedAt() time.Time {
+	return e.keyEventCore.CreatedAt
+}
+
+func (e keyEvent) Save(dbMap DataMapper) error {
+	if e.Id() > 0 {
+		_, err := dbMap.Update(e.keyEventCore)
+		return err
+	}
+	return dbMap.Insert(e.keyEventCore)
+}
+
+func newKeyEvent(publicKeyId, messageId int, text string, dbMap DataMapper) (KeyEvent, error) {
+	e := &keyEvent{&keyEventCore{
+		PublicKeyId: publicKeyId,
+		MessageId:   messageId,
+		Text:        text,
+		CreatedAt:   time.Now().UTC(),
+	}}
+	if err := e.Save(dbMap); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewMessageEvent queues the delivery of a message to the key it was encrypted to
+func NewMessageEvent(publicKeyId, messageId int, dbMap DataMapper) (KeyEvent, error) {
+	if publicKeyId == 0 || messageId == 0 {
+		return nil, InvalidArgumentsForMessageError
+	}
+	return newKeyEvent(publicKeyId, messageId, "", dbMap)
+}
+
+// NewNotificationEvents queues a notification for every active key of the user. The events are returned by key id.
+func NewNotificationEvents(userId int, text string, dbMap DataMapper) (map[int]KeyEvent, error) {
+	u, err := FindUserWithId(userId, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := u.ActivePublicKeys(dbMap)
+	if err != nil {
+		return nil, err
+	}
+	ret := make(map[int]KeyEvent)
+	for _, k := range keys {
+		e, err := newKeyEvent(k.Id(), 0, text, dbMap)
+		if err != nil {
+			return nil, err
+		}
+		ret[k.Id()] = e
+	}
+	return ret, nil
+}
+
+func findUndeliveredEvents(publicKeyId, lastEventId int, dbMap DataMapper) ([]KeyEvent, error) {
+	var ret []KeyEvent
+	var events []*keyEventCore
+	_, err := dbMap.Select(&events, "SELECT * FROM key_events WHERE public_key_id = ? AND id > ? ORDER BY id ASC", publicKeyId, lastEventId)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range events {
+		ret = append(ret, &keyEvent{e})
+	}
+	return ret, nil
+}
+
+// PurgeStaleEvents drops events that were never acknowledged and events for messages that no longer exist.
+// It returns the number of events deleted.
+func PurgeStaleEvents(dbMap DataMapper) (int, error) {
+	res, err := dbMap.Exec("DELETE FROM key_events WHERE created_at <= ? OR (message_id != 0 AND message_id NOT IN (SELECT id FROM encrypted_messages))",
+		time.Now().UTC().Add(-EVENT_RETENTION))
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
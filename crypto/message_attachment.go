@@ -0,0 +1,116 @@
+package crypto
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"github.com/rajivnavada/gpgme"
+	"time"
+)
+
+type messageAttachmentCore struct {
+	Id          int       `db:"id"`
+	MessageId   int       `db:"message_id"`
+	Filename    string    `db:"filename"`
+	ContentType string    `db:"content_type"`
+	Size        int64     `db:"size"`
+	Cipher      []byte    `db:"cipher"`
+	CreatedAt   time.Time `db:"created_at"`
+}
+
+// messageAttachment is a file sent along with a message. The cipher holds the base64 encoded file encrypted to the key of the message.
+type messageAttachment struct {
+	*messageAttachmentCore
+}
+
+func (ma messageAttachment) Id() int {
+	return ma.messageAttachmentCore.Id
+}
+
+func (ma messageAttachment) MessageId() int {
+	return ma.messageAttachmentCore.MessageId
+}
+
+func (ma messageAttachment) Filename() string {
+	return ma.messageAttachmentCore.Filename
+}
+
+func (ma messageAttachment) ContentType() string {
+	return ma.messageAttachmentCore.ContentType
+}
+
+// Size is the size of the original file in bytes
+func (ma messageAttachment) Size() int64 {
+	return ma.messageAttachmentCore.Size
+}
+
+func (ma messageAttachment) Cipher() []byte {
+	return ma.messageAttachmentCore.Cipher
+}
+
+func (ma messageAttachment) CreatedAt() time.Time {
+	return ma.messageAttachmentCore.CreatedAt
+}
+
+func (ma messageAttachment) Save(dbMap DataMapper) error {
+	if ma.Id() > 0 {
+		_, err := dbMap.Update(ma.messageAttachmentCore)
+		return err
+	}
+	return dbMap.Insert(ma.messageAttachmentCore)
+}
+
+// AttachmentFile is a file to attach to a message before it is encrypted
+type AttachmentFile struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// encryptAndSaveAttachment encrypts the file to the key and stores it with the message.
+// gpgme only encrypts C strings so the file is base64 encoded first.
+func encryptAndSaveAttachment(f AttachmentFile, k PublicKey, messageId int, dbMap DataMapper) (MessageAttachment, error) {
+	cipher, err := gpgme.EncryptMessage(base64.StdEncoding.EncodeToString(f.Data), k.Fingerprint())
+	if err != nil {
+		return nil, err
+	}
+	ma := &messageAttachment{&messageAttachmentCore{
+		MessageId:   messageId,
+		Filename:    f.Filename,
+		ContentType: f.ContentType,
+		Size:        int64(len(f.Data)),
+		Cipher:      []byte(cipher),
+		CreatedAt:   time.Now().UTC(),
+	}}
+	if err = ma.Save(dbMap); err != nil {
+		return nil, err
+	}
+	return ma, nil
+}
+
+// findAttachmentsForMessage returns the attachments of a message without their ciphers
+func findAttachmentsForMessage(messageId int, dbMap DataMapper) ([]MessageAttachment, error) {
+	var ret []MessageAttachment
+	var attachments []*messageAttachmentCore
+	_, err := dbMap.Select(&attachments, "SELECT id, message_id, filename, content_type, size, created_at FROM message_attachments WHERE message_id = ? ORDER BY id ASC", messageId)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		ret = append(ret, &messageAttachment{a})
+	}
+	return ret, nil
+}
+
+// FindAttachmentForPublicKey returns an attachment if the message it belongs to was encrypted to the key
+func FindAttachmentForPublicKey(id, publicKeyId int, dbMap DataMapper) (MessageAttachment, error) {
+	ac := &messageAttachmentCore{}
+	err := dbMap.SelectOne(ac, "SELECT a.* FROM message_attachments a INNER JOIN encrypted_messages m ON m.id = a.message_id WHERE a.id = ? AND m.public_key_id = ? AND (m.expires_at IS NULL OR m.expires_at = ? OR m.expires_at > ?)",
+		id, publicKeyId, time.Time{}, time.Now().UTC())
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, AttachmentNotFoundError
+		}
+		return nil, err
+	}
+	return &messageAttachment{ac}, nil
+}
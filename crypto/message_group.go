@@ -0,0 +1,114 @@
+package crypto
+
+import (
+	"time"
+)
+
+type messageGroupCore struct {
+	Id        int       `db:"id"`
+	SenderId  int       `db:"sender_id"`
+	ProjectId int       `db:"project_id"`
+	Subject   string    `db:"subject"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type messageRecipientCore struct {
+	Id             int `db:"id"`
+	MessageGroupId int `db:"message_group_id"`
+	UserId         int `db:"user_id"`
+}
+
+// messageGroup ties together the per key ciphers of a message addressed to several users
+type messageGroup struct {
+	*messageGroupCore
+}
+
+func (mg messageGroup) Id() int {
+	return mg.messageGroupCore.Id
+}
+
+func (mg messageGroup) Save(dbMap DataMapper) error {
+	if mg.Id() > 0 {
+		_, err := dbMap.Update(mg.messageGroupCore)
+		return err
+	}
+	return dbMap.Insert(mg.messageGroupCore)
+}
+
+func (mg messageGroup) addRecipient(userId int, dbMap DataMapper) error {
+	return dbMap.Insert(&messageRecipientCore{
+		MessageGroupId: mg.Id(),
+		UserId:         userId,
+	})
+}
+
+func findMessageGroupRecipients(messageGroupId int, dbMap DataMapper) ([]User, error) {
+	var ret []User
+	var users []*userCore
+	_, err := dbMap.Select(&users, "SELECT u.* FROM users u INNER JOIN message_recipients mr ON mr.user_id = u.id WHERE mr.message_group_id = ? ORDER BY mr.id ASC", messageGroupId)
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range users {
+		ret = append(ret, &user{u})
+	}
+	return ret, nil
+}
+
+// encryptAndSaveForGroup records the recipients and encrypts the message to every active key they have
+func encryptAndSaveForGroup(sender User, recipients []User, projectId int, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+	if sender == nil || len(recipients) == 0 {
+		return nil, InvalidArgumentsForMessageError
+	}
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+
+	// The group only keeps the subject if it is not encrypted
+	_, groupSubject := opts.envelope("", subject)
+	g := &messageGroup{&messageGroupCore{
+		SenderId:  sender.Id(),
+		ProjectId: projectId,
+		Subject:   groupSubject,
+		CreatedAt: time.Now().UTC(),
+	}}
+	if err := g.Save(dbMap); err != nil {
+		return nil, err
+	}
+
+	var kc []PublicKey
+	seen := make(map[int]bool)
+	var added []User
+	for _, u := range recipients {
+		if seen[u.Id()] {
+			continue
+		}
+		seen[u.Id()] = true
+		if err := g.addRecipient(u.Id(), dbMap); err != nil {
+			return nil, err
+		}
+		added = append(added, u)
+
+		keys, err := u.ActivePublicKeys(dbMap)
+		if err != nil {
+			return nil, err
+		}
+		kc = append(kc, keys...)
+	}
+
+	ret, err := encryptAndSaveForKeys(sender, kc, message, subject, opts, g.Id(), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range ret {
+		if em, ok := m.(*encryptedMessage); ok {
+			em.encryptedMessageCore.recipients = added
+		}
+	}
+	return ret, nil
+}
+
+// EncryptAndSaveForUsers sends a single message to a list of users. Each active key of each recipient gets its own cipher.
+func EncryptAndSaveForUsers(sender User, recipients []User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+	return encryptAndSaveForGroup(sender, recipients, 0, message, subject, opts, dbMap)
+}
@@ -0,0 +1,207 @@
+package crypto
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Changes made to schema.sql after databases were created from it, oldest first. The database's user_version is the
+// number of migrations applied to it, schema.sql sets it to the latest. Add a migration for every change to
+// schema.sql, and never change one that has shipped.
+var migrations = []string{
+	// 1: service accounts
+	`ALTER TABLE users ADD COLUMN "user_type" varchar(255) not null DEFAULT 'human';
+
+	CREATE TABLE IF NOT EXISTS "service_account_usage" (
+	    "id" integer not null primary key autoincrement,
+	    "user_id" integer not null,
+	    "project_id" integer not null,
+	    "credential_id" integer not null,
+	    "public_key_id" integer not null,
+	    "accessed_at" datetime not null,
+	    FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	    FOREIGN KEY("project_id") REFERENCES projects(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	    FOREIGN KEY("credential_id") REFERENCES project_credential_keys(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_sau_project_id ON service_account_usage(project_id);`,
+
+	// 2: server administrators and suspended users
+	`ALTER TABLE users ADD COLUMN "is_admin" boolean not null DEFAULT 0;
+	ALTER TABLE users ADD COLUMN "suspended_at" datetime;
+
+	-- suspended_at is read into a time.Time, which can't hold NULL
+	UPDATE users SET suspended_at = '0001-01-01 00:00:00+00:00' WHERE suspended_at IS NULL;`,
+
+	// 3: revoked keys
+	`ALTER TABLE public_keys ADD COLUMN "revoked_at" datetime;
+
+	-- revoked_at is read into a time.Time, which can't hold NULL
+	UPDATE public_keys SET revoked_at = '0001-01-01 00:00:00+00:00' WHERE revoked_at IS NULL;`,
+
+	// 4: the vault
+	`CREATE TABLE IF NOT EXISTS "user_credentials" (
+	    "id" integer not null primary key autoincrement,
+	    "user_id" integer not null,
+	    "public_key_id" integer not null,
+	    "key" varchar(255) not null,
+	    "cipher" blob not null,
+	    "created_at" datetime not null,
+	    "updated_at" datetime not null,
+	    FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS uniq_uc_public_key_id_key ON user_credentials(public_key_id, key);`,
+
+	// 5: messages to several users or a project
+	`ALTER TABLE encrypted_messages ADD COLUMN "message_group_id" integer not null DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS "message_groups" (
+	    "id" integer not null primary key autoincrement,
+	    "sender_id" integer not null,
+	    "project_id" integer not null DEFAULT 0,
+	    "subject" varchar(255),
+	    "created_at" datetime not null,
+	    FOREIGN KEY("sender_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE TABLE IF NOT EXISTS "message_recipients" (
+	    "id" integer not null primary key autoincrement,
+	    "message_group_id" integer not null,
+	    "user_id" integer not null,
+	    FOREIGN KEY("message_group_id") REFERENCES message_groups(id) ON UPDATE CASCADE ON DELETE CASCADE,
+	    FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS uniq_mr_message_group_id_user_id ON message_recipients(message_group_id, user_id);`,
+
+	// 6: inbox read state
+	`ALTER TABLE encrypted_messages ADD COLUMN "read_at" datetime;
+
+	-- read_at is read into a time.Time, which can't hold NULL
+	UPDATE encrypted_messages SET read_at = '0001-01-01 00:00:00+00:00' WHERE read_at IS NULL;
+
+	CREATE INDEX IF NOT EXISTS idx_em_public_key_id_created_at ON encrypted_messages(public_key_id, created_at);`,
+
+	// 7: self-destructing messages
+	`ALTER TABLE encrypted_messages ADD COLUMN "expires_at" datetime;
+	ALTER TABLE encrypted_messages ADD COLUMN "view_once" boolean not null DEFAULT 0;
+
+	-- expires_at is read into a time.Time, which can't hold NULL
+	UPDATE encrypted_messages SET expires_at = '0001-01-01 00:00:00+00:00' WHERE expires_at IS NULL;`,
+
+	// 8: encrypted subjects
+	`ALTER TABLE encrypted_messages ADD COLUMN "subject_encrypted" boolean not null DEFAULT 0;`,
+
+	// 9: threaded replies
+	`ALTER TABLE encrypted_messages ADD COLUMN "parent_id" integer not null DEFAULT 0;
+	ALTER TABLE encrypted_messages ADD COLUMN "thread_id" integer not null DEFAULT 0;
+
+	CREATE INDEX IF NOT EXISTS idx_em_thread_id ON encrypted_messages(thread_id);`,
+
+	// 10: message attachments
+	`CREATE TABLE IF NOT EXISTS "message_attachments" (
+	    "id" integer not null primary key autoincrement,
+	    "message_id" integer not null,
+	    "filename" varchar(255) not null,
+	    "content_type" varchar(255),
+	    "size" integer not null DEFAULT 0,
+	    "cipher" blob not null,
+	    "created_at" datetime not null,
+	    FOREIGN KEY("message_id") REFERENCES encrypted_messages(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ma_message_id ON message_attachments(message_id);`,
+
+	// 11: binary credential values
+	`ALTER TABLE project_credential_values ADD COLUMN "binary" boolean not null DEFAULT 0;
+	ALTER TABLE user_credentials ADD COLUMN "binary" boolean not null DEFAULT 0;`,
+
+	// 12: queued events
+	`ALTER TABLE public_keys ADD COLUMN "last_event_id" integer not null DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS "key_events" (
+	    "id" integer not null primary key autoincrement,
+	    "public_key_id" integer not null,
+	    "message_id" integer not null DEFAULT 0,
+	    "text" text,
+	    "created_at" datetime not null,
+	    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_ke_public_key_id_id ON key_events(public_key_id, id);`,
+
+	// 13: broadcasts between instances
+	`CREATE TABLE IF NOT EXISTS "hub_broadcasts" (
+	    "id" integer not null primary key autoincrement,
+	    "origin" varchar(64) not null,
+	    "payload" blob not null,
+	    "created_at" datetime not null
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_hb_created_at ON hub_broadcasts(created_at);`,
+
+	// 14: API tokens
+	`CREATE TABLE IF NOT EXISTS "api_tokens" (
+	    "id" integer not null primary key autoincrement,
+	    "public_key_id" integer not null,
+	    "token_hash" varchar(64) not null,
+	    "created_at" datetime not null,
+	    "expires_at" datetime not null,
+	    "last_used_at" datetime not null,
+	    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+	);
+
+	CREATE UNIQUE INDEX IF NOT EXISTS uniq_at_token_hash ON api_tokens(token_hash);
+	CREATE INDEX IF NOT EXISTS idx_at_expires_at ON api_tokens(expires_at);`,
+}
+
+// MigrateDatabase applies the migrations the database at SqliteFilePath is missing. Each one is applied in a
+// transaction along with the new user_version, so a migration that fails leaves the database as it was.
+func MigrateDatabase() error {
+	db, err := sql.Open("sqlite3", SqliteFilePath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version := 0
+	if err = db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("Database is at version %d, this build only knows about %d.", version, len(migrations))
+	}
+
+	// The database hasn't been created from schema.sql yet, there's nothing to migrate
+	if version == 0 {
+		tables := 0
+		if err = db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'").Scan(&tables); err != nil {
+			return err
+		}
+		if tables == 0 {
+			return nil
+		}
+	}
+
+	for ; version < len(migrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(migrations[version]); err == nil {
+			// PRAGMA statements don't take parameters
+			_, err = tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", version+1))
+		}
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("Migrating the database to version %d failed: %s", version+1, err)
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package crypto
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// createDatabase creates a database in dir from the schema in schemaFile
+func createDatabase(t *testing.T, dir, schemaFile string) string {
+	schema, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, filepath.Base(schemaFile)+".db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(string(schema)); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// describeDatabase lists the columns of every table and the indexes, along with the user_version
+func describeDatabase(t *testing.T, path string) []string {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var ret []string
+	version := ""
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatal(err)
+	}
+	ret = append(ret, "version "+version)
+
+	rows, err := db.Query("SELECT type, name FROM sqlite_master WHERE name NOT LIKE 'sqlite_%'")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tables []string
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			t.Fatal(err)
+		}
+		ret = append(ret, kind+" "+name)
+		if kind == "table" {
+			tables = append(tables, name)
+		}
+	}
+	rows.Close()
+
+	for _, table := range tables {
+		rows, err := db.Query("PRAGMA table_info(" + table + ")")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for rows.Next() {
+			var cid, notNull, pk int
+			var name, kind string
+			var defaultValue sql.NullString
+			if err := rows.Scan(&cid, &name, &kind, &notNull, &defaultValue, &pk); err != nil {
+				t.Fatal(err)
+			}
+			// Quoting of defaults differs between the schema and the migrations
+			ret = append(ret, strings.Join([]string{"column", table, name, kind}, " "))
+		}
+		rows.Close()
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// insertRows adds a user with a key and a message the way the first schema stored them
+func insertRows(t *testing.T, path string) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	now := time.Now().UTC()
+	for _, q := range []struct {
+		query string
+		args  []interface{}
+	}{
+		{"INSERT INTO users (id, name, email, comment, created_at, updated_at) VALUES (1, 'Old', 'old@example.com', '', ?, ?)", []interface{}{now, now}},
+		{"INSERT INTO public_keys (id, user_id, fingerprint, created_at, updated_at, activated_at, expires_at) VALUES (1, 1, 'OLD', ?, ?, ?, ?)", []interface{}{now, now, now, time.Time{}}},
+		{"INSERT INTO encrypted_messages (id, sender_id, public_key_id, subject, cipher, created_at, updated_at) VALUES (1, 1, 1, 'Hi', 'cipher', ?, ?)", []interface{}{now, now}},
+	} {
+		if _, err := db.Exec(q.query, q.args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// loadRows checks the rows added by insertRows can still be read once the database is migrated
+func loadRows(t *testing.T) {
+	dbMap, err := NewDataMapper()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dbMap.Close()
+
+	if _, err := FindUserWithId(1, dbMap); err != nil {
+		t.Fatal(err)
+	}
+	u, err := FindUserWithEmail("old@example.com", dbMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.IsServiceAccount() {
+		t.Fatal("Expected the user to not be a service account after migrating")
+	}
+	if u.IsSuspended() {
+		t.Fatal("Expected the user to not be suspended after migrating")
+	}
+	k, err := FindPublicKeyWithFingerprint("OLD", dbMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages, _, err := k.FindMessages(MessageFilter{UnreadOnly: true}, dbMap)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(messages) != 1 {
+		t.Fatalf("Expected the message to be unread after migrating, found %d unread messages", len(messages))
+	}
+	if _, err := FindMessageForPublicKey(1, k.Id(), dbMap); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMigrateDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cryptzd-migrations")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A database created from the first schema ends up the same as one created from the current one
+	SqliteFilePath = createDatabase(t, dir, "testdata/schema_v0.sql")
+	insertRows(t, SqliteFilePath)
+	if err := MigrateDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	migrated := describeDatabase(t, SqliteFilePath)
+	loadRows(t)
+
+	SqliteFilePath = createDatabase(t, dir, "../schema.sql")
+	if err := MigrateDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	current := describeDatabase(t, SqliteFilePath)
+
+	if strings.Join(migrated, "\n") != strings.Join(current, "\n") {
+		t.Fatalf("Migrated database\n%s\n\ndoes not match schema.sql\n%s", strings.Join(migrated, "\n"), strings.Join(current, "\n"))
+	}
+}
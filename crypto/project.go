@@ -50,7 +50,20 @@ func (p project) UpdatedAt() time.Time {
 
 func (p project) HasAdminWithUserId(userId int, dbMap DataMapper) bool {
 	ret := 0
-	err := dbMap.SelectOne(&ret, "SELECT 1 FROM project_members WHERE user_id = ? AND access_level = ?", userId, "admin")
+	err := dbMap.SelectOne(&ret, "SELECT 1 FROM project_members WHERE project_id = ? AND user_id = ? AND access_level = ?", p.Id(), userId, ACCESS_LEVEL_ADMIN)
+	return err == nil && ret == 1
+}
+
+// HasAdminOtherThanUserId reports whether someone besides userId can administer the project
+func (p project) HasAdminOtherThanUserId(userId int, dbMap DataMapper) bool {
+	ret := 0
+	err := dbMap.SelectOne(&ret, "SELECT 1 FROM project_members WHERE project_id = ? AND user_id != ? AND access_level = ? LIMIT 1", p.Id(), userId, ACCESS_LEVEL_ADMIN)
+	return err == nil && ret == 1
+}
+
+func (p project) HasMemberWithUserId(userId int, dbMap DataMapper) bool {
+	ret := 0
+	err := dbMap.SelectOne(&ret, "SELECT 1 FROM project_members WHERE project_id = ? AND user_id = ?", p.Id(), userId)
 	return err == nil && ret == 1
 }
 
@@ -73,13 +86,27 @@ func (p project) AddMember(userId int, accessLevel string, dbMap DataMapper) (Pr
 	if err != nil && err != sql.ErrNoRows {
 		return nil, err
 	}
-	// If it does not exist, create and save the record
-	if err == sql.ErrNoRows {
-		pm = NewProjectMember(userId, p.Id(), accessLevel)
-		err = pm.Save(dbMap)
+	if err == nil && pm.AccessLevel() == accessLevel {
+		return pm, nil
+	}
+	// Service accounts are scoped to read-only access
+	if accessLevel != ACCESS_LEVEL_READ {
+		u, err := FindUserWithId(userId, dbMap)
 		if err != nil {
 			return nil, err
 		}
+		if u.IsServiceAccount() {
+			return nil, ServiceAccountReadOnlyError
+		}
+	}
+	// If it does not exist, create the record. Otherwise the member gets the new access level.
+	if err == sql.ErrNoRows {
+		pm = NewProjectMember(userId, p.Id(), accessLevel)
+	} else {
+		pm.SetAccessLevel(accessLevel)
+	}
+	if err = pm.Save(dbMap); err != nil {
+		return nil, err
 	}
 	return pm, nil
 }
@@ -120,6 +147,9 @@ func (p project) GetCredential(key string, publicKeyId int, dbMap DataMapper) (P
 }
 
 func (p project) SetCredential(key, value string, dbMap DataMapper) (ProjectCredentialKey, error) {
+	if int64(len(value)) > MaxCredentialSize {
+		return nil, CredentialTooLargeError
+	}
 	// Figure out if the combo of key & p.Id exists
 	pk, err := FindProjectCredentialKey(key, p.Id(), dbMap)
 	if err != nil && err != sql.ErrNoRows {
@@ -147,7 +177,7 @@ func (p project) SetCredential(key, value string, dbMap DataMapper) (ProjectCred
 			return nil, err
 		}
 		for _, k := range keys {
-			cipher, err := k.Encrypt(value)
+			cipher, binary, err := encryptCredential(k, value)
 			if err != nil {
 				return nil, err
 			}
@@ -161,13 +191,14 @@ func (p project) SetCredential(key, value string, dbMap DataMapper) (ProjectCred
 					CredentialId: pk.Id(),
 					MemberId:     m.Id(),
 					PublicKeyId:  k.Id(),
-					Cipher:       []byte(cipher),
+					Cipher:       cipher,
+					Binary:       binary,
 					CreatedAt:    currentTime,
 					UpdatedAt:    currentTime,
 					ExpiresAt:    currentTime.AddDate(0, 3, 0),
 				}}
 			} else {
-				pv.SetCipher([]byte(cipher))
+				pv.SetCipher(cipher, binary)
 			}
 			if err := pv.Save(dbMap); err != nil {
 				return nil, err
@@ -191,6 +222,48 @@ func (p project) RemoveCredential(key string, dbMap DataMapper) error {
 	return pk.Delete(dbMap)
 }
 
+func (p project) ServiceAccountUsage(dbMap DataMapper) ([]ServiceAccountUsage, error) {
+	var ret []ServiceAccountUsage
+	var usage []*serviceAccountUsageSummaryCore
+	_, err := dbMap.Select(&usage, `SELECT u.id AS user_id, u.email AS email, pck.key AS key, COUNT(sau.id) AS count, MAX(sau.accessed_at) AS last_accessed_at
+		FROM service_account_usage sau
+		INNER JOIN users u ON u.id = sau.user_id
+		INNER JOIN project_credential_keys pck ON pck.id = sau.credential_id
+		WHERE sau.project_id = ?
+		GROUP BY u.id, u.email, pck.key
+		ORDER BY u.email ASC, pck.key ASC`, p.Id())
+	if err != nil {
+		return nil, err
+	}
+	for _, u := range usage {
+		ret = append(ret, &serviceAccountUsageSummary{u})
+	}
+	return ret, nil
+}
+
+// EncryptAndSave sends a message to every member of the project. Service accounts and suspended users are skipped.
+func (p project) EncryptAndSave(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+	members, err := p.Members(dbMap)
+	if err != nil {
+		return nil, err
+	}
+	var recipients []User
+	for _, m := range members {
+		u, err := m.User(dbMap)
+		if err != nil {
+			return nil, err
+		}
+		if u.IsServiceAccount() || u.IsSuspended() {
+			continue
+		}
+		recipients = append(recipients, u)
+	}
+	if len(recipients) == 0 {
+		return nil, NoProjectRecipientsError
+	}
+	return encryptAndSaveForGroup(sender, recipients, p.Id(), message, subject, opts, dbMap)
+}
+
 func (p project) Save(dbMap DataMapper) error {
 	if p.Id() > 0 {
 		_, err := dbMap.Update(p.projectCore)
@@ -10,6 +10,7 @@ type projectCredentialValueCore struct {
 	MemberId     int       `db:"member_id"`
 	PublicKeyId  int       `db:"public_key_id"`
 	Cipher       []byte    `db:"cipher"`
+	Binary       bool      `db:"binary"`
 	CreatedAt    time.Time `db:"created_at"`
 	UpdatedAt    time.Time `db:"updated_at"`
 	ExpiresAt    time.Time `db:"expires_at"`
@@ -39,8 +40,14 @@ func (pv projectCredentialValue) Cipher() []byte {
 	return pv.projectCredentialValueCore.Cipher
 }
 
-func (pv *projectCredentialValue) SetCipher(cipher []byte) {
+// Binary reports if the value was base64 encoded before it was encrypted
+func (pv projectCredentialValue) Binary() bool {
+	return pv.projectCredentialValueCore.Binary
+}
+
+func (pv *projectCredentialValue) SetCipher(cipher []byte, binary bool) {
 	pv.projectCredentialValueCore.Cipher = cipher
+	pv.projectCredentialValueCore.Binary = binary
 	pv.projectCredentialValueCore.UpdatedAt = time.Now().UTC()
 }
 
@@ -64,13 +71,14 @@ func (pv projectCredentialValue) Save(dbMap DataMapper) error {
 	return dbMap.Insert(pv.projectCredentialValueCore)
 }
 
-func NewProjectCredentialValue(credentialId, memberId, keyId int, cipher []byte) ProjectCredentialValue {
+func NewProjectCredentialValue(credentialId, memberId, keyId int, cipher []byte, binary bool) ProjectCredentialValue {
 	currentTime := time.Now().UTC()
 	return &projectCredentialValue{&projectCredentialValueCore{
 		CredentialId: credentialId,
 		MemberId:     memberId,
 		PublicKeyId:  keyId,
 		Cipher:       cipher,
+		Binary:       binary,
 		CreatedAt:    currentTime,
 		UpdatedAt:    currentTime,
 		ExpiresAt:    currentTime.AddDate(1, 0, 0),
@@ -33,6 +33,11 @@ func (pm projectMember) AccessLevel() string {
 	return pm.projectMemberCore.AccessLevel
 }
 
+func (pm projectMember) SetAccessLevel(accessLevel string) {
+	pm.projectMemberCore.AccessLevel = accessLevel
+	pm.projectMemberCore.UpdatedAt = time.Now().UTC()
+}
+
 func (pm projectMember) CreatedAt() time.Time {
 	return pm.projectMemberCore.CreatedAt
 }
@@ -2,9 +2,12 @@ package crypto
 
 import (
 	"database/sql"
+	"encoding/base64"
 	"github.com/rajivnavada/gpgme"
 	"log"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 type publicKeyCore struct {
@@ -16,6 +19,8 @@ type publicKeyCore struct {
 	UpdatedAt   time.Time `db:"updated_at"`
 	ActivatedAt time.Time `db:"activated_at"`
 	ExpiresAt   time.Time `db:"expires_at"`
+	RevokedAt   time.Time `db:"revoked_at"`
+	LastEventId int       `db:"last_event_id"`
 }
 
 type publicKey struct {
@@ -50,6 +55,15 @@ func (k publicKey) Active() bool {
 	return !k.publicKeyCore.ActivatedAt.IsZero()
 }
 
+func (k publicKey) Expired() bool {
+	return !k.publicKeyCore.ExpiresAt.IsZero() && !k.publicKeyCore.ExpiresAt.After(time.Now().UTC())
+}
+
+// Revoked reports whether the key was revoked. Importing the key again doesn't undo it.
+func (k publicKey) Revoked() bool {
+	return !k.publicKeyCore.RevokedAt.IsZero()
+}
+
 func (k publicKey) CreatedAt() time.Time {
 	return k.publicKeyCore.CreatedAt
 }
@@ -70,6 +84,58 @@ func (k *publicKey) SetExpiresAt(t time.Time) {
 	k.publicKeyCore.ExpiresAt = t
 }
 
+// LastEventId is the id of the last event the key acknowledged
+func (k publicKey) LastEventId() int {
+	return k.publicKeyCore.LastEventId
+}
+
+// UndeliveredEvents returns the events the key has not acknowledged yet, oldest first
+func (k publicKey) UndeliveredEvents(dbMap DataMapper) ([]KeyEvent, error) {
+	return findUndeliveredEvents(k.Id(), k.LastEventId(), dbMap)
+}
+
+// AckEvents acknowledges every event up to and including eventId. Acknowledged events are removed from the queue.
+// Events that haven't been queued yet can't be acknowledged, eventId is capped at the last one queued for the key.
+func (k *publicKey) AckEvents(eventId int, dbMap DataMapper) error {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return err
+	}
+	eventId, err = k.ackEvents(eventId, tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	if eventId > k.publicKeyCore.LastEventId {
+		k.publicKeyCore.LastEventId = eventId
+	}
+	return nil
+}
+
+func (k *publicKey) ackEvents(eventId int, dbMap DataMapper) (int, error) {
+	lastId := 0
+	if err := dbMap.SelectOne(&lastId, "SELECT COALESCE(MAX(id), 0) FROM key_events WHERE public_key_id = ?", k.Id()); err != nil {
+		return 0, err
+	}
+	if eventId > lastId {
+		eventId = lastId
+	}
+	if eventId <= k.publicKeyCore.LastEventId {
+		return eventId, nil
+	}
+	// Only last_event_id changes, the rest of the key may have been changed since it was loaded
+	if _, err := dbMap.Exec("UPDATE public_keys SET last_event_id = ? WHERE id = ? AND last_event_id < ?", eventId, k.Id(), eventId); err != nil {
+		return 0, err
+	}
+	if _, err := dbMap.Exec("DELETE FROM key_events WHERE public_key_id = ? AND id <= ?", k.Id(), eventId); err != nil {
+		return 0, err
+	}
+	return eventId, nil
+}
+
 func (k publicKey) User(dbMap DataMapper) User {
 	if k.publicKeyCore.UserId == 0 {
 		return nil
@@ -91,13 +157,35 @@ func (k publicKey) Encrypt(msg string) (string, error) {
 	return cipher, nil
 }
 
-func (k publicKey) EncryptAndSave(sender User, t, subject string, dbMap DataMapper) (EncryptedMessage, error) {
+// encryptCredential encrypts a credential value to the key. gpgme only encrypts C strings, so values
+// that are not valid UTF-8 text or contain NUL bytes are base64 encoded first and reported as binary.
+func encryptCredential(k PublicKey, value string) ([]byte, bool, error) {
+	binary := !utf8.ValidString(value) || strings.IndexByte(value, 0) >= 0
+	if binary {
+		value = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	cipher, err := k.Encrypt(value)
+	if err != nil {
+		return nil, false, err
+	}
+	return []byte(cipher), binary, nil
+}
+
+func (k publicKey) EncryptAndSave(sender User, t, subject string, opts MessageOptions, dbMap DataMapper) (EncryptedMessage, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
+	return encryptAndSaveForKey(&k, sender, t, subject, opts, 0, dbMap)
+}
+
+func encryptAndSaveForKey(k PublicKey, sender User, t, subject string, opts MessageOptions, messageGroupId int, dbMap DataMapper) (EncryptedMessage, error) {
+	t, subject = opts.envelope(t, subject)
 	cipher, err := gpgme.EncryptMessage(t, k.Fingerprint())
 	if err != nil {
 		return nil, err
 	}
 
-	msg, err := newMessage(k.Id(), sender.Id(), []byte(cipher), subject)
+	msg, err := newMessage(k.Id(), sender.Id(), messageGroupId, []byte(cipher), subject, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -107,6 +195,14 @@ func (k publicKey) EncryptAndSave(sender User, t, subject string, dbMap DataMapp
 		return nil, err
 	}
 
+	for _, f := range opts.Attachments {
+		a, err := encryptAndSaveAttachment(f, k, msg.Id(), dbMap)
+		if err != nil {
+			return nil, err
+		}
+		msg.encryptedMessageCore.attachments = append(msg.encryptedMessageCore.attachments, a)
+	}
+
 	msg.encryptedMessageCore.sender = sender
 	return msg, nil
 }
@@ -117,16 +213,85 @@ func (k *publicKey) Activate() {
 	}
 }
 
+func (k *publicKey) Revoke() {
+	currentTime := time.Now().UTC()
+	if k.publicKeyCore.RevokedAt.IsZero() {
+		k.publicKeyCore.RevokedAt = currentTime
+	}
+	k.publicKeyCore.UpdatedAt = currentTime
+}
+
 func (k *publicKey) Messages(dbMap DataMapper) ([]EncryptedMessage, error) {
-	var ret []EncryptedMessage
 	var messages []*encryptedMessageCore
-	_, err := dbMap.Select(&messages, "SELECT * FROM encrypted_messages WHERE public_key_id = ? ORDER BY created_at DESC", k.Id())
+	_, err := dbMap.Select(&messages, "SELECT * FROM encrypted_messages WHERE public_key_id = ? AND (expires_at IS NULL OR expires_at = ? OR expires_at > ?) ORDER BY created_at DESC",
+		k.Id(), time.Time{}, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
+	return loadMessages(messages, dbMap)
+}
+
+// FindMessages returns a page of the messages matching the filter along with the total number of matching messages
+func (k *publicKey) FindMessages(filter MessageFilter, dbMap DataMapper) ([]EncryptedMessage, int, error) {
+	where, args := filter.whereClause()
+	args = append([]interface{}{k.Id()}, args...)
+
+	total := 0
+	err := dbMap.SelectOne(&total, "SELECT COUNT(*) FROM encrypted_messages m INNER JOIN users u ON u.id = m.sender_id WHERE m.public_key_id = ?"+where, args...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	limit, offset := filter.limits()
+	var messages []*encryptedMessageCore
+	_, err = dbMap.Select(&messages, "SELECT m.* FROM encrypted_messages m INNER JOIN users u ON u.id = m.sender_id WHERE m.public_key_id = ?"+where+" ORDER BY m.created_at DESC LIMIT ? OFFSET ?",
+		append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ret, err := loadMessages(messages, dbMap)
+	if err != nil {
+		return nil, 0, err
+	}
+	return ret, total, nil
+}
+
+// Thread returns the messages of a conversation this key can read along with the messages its user sent in it, oldest first.
+// A sent message is encrypted to every key of the recipient so only one copy of it is returned.
+func (k *publicKey) Thread(threadId int, dbMap DataMapper) ([]EncryptedMessage, error) {
+	var messages []*encryptedMessageCore
+	_, err := dbMap.Select(&messages, "SELECT * FROM encrypted_messages WHERE (thread_id = ? OR id = ?) AND (public_key_id = ? OR sender_id = ?) AND (expires_at IS NULL OR expires_at = ? OR expires_at > ?) ORDER BY created_at ASC, id ASC",
+		threadId, threadId, k.Id(), k.UserId(), time.Time{}, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	var thread []*encryptedMessageCore
+	seenGroups := make(map[int]bool)
+	for _, m := range messages {
+		if m.PublicKeyId != k.Id() && m.MessageGroupId > 0 {
+			if seenGroups[m.MessageGroupId] {
+				continue
+			}
+			seenGroups[m.MessageGroupId] = true
+		}
+		thread = append(thread, m)
+	}
+	return loadMessages(thread, dbMap)
+}
+
+func loadMessages(messages []*encryptedMessageCore, dbMap DataMapper) ([]EncryptedMessage, error) {
+	var ret []EncryptedMessage
 	for _, m := range messages {
 		// TODO: we could possibly load the sender into the message using JOIN
-		if err = m.loadSender(dbMap); err != nil {
+		if err := m.loadSender(dbMap); err != nil {
+			return nil, err
+		}
+		if err := m.loadRecipients(dbMap); err != nil {
+			return nil, err
+		}
+		if err := m.loadAttachments(dbMap); err != nil {
 			return nil, err
 		}
 		ret = append(ret, &encryptedMessage{m})
@@ -134,6 +299,11 @@ func (k *publicKey) Messages(dbMap DataMapper) ([]EncryptedMessage, error) {
 	return ret, nil
 }
 
+func (k publicKey) Delete(dbMap DataMapper) error {
+	_, err := dbMap.Delete(k.publicKeyCore)
+	return err
+}
+
 func (k publicKey) Save(dbMap DataMapper) error {
 	if k.Id() > 0 {
 		_, err := dbMap.Update(k.publicKeyCore)
@@ -0,0 +1,57 @@
+package crypto
+
+import (
+	"time"
+)
+
+type serviceAccountUsageCore struct {
+	Id           int       `db:"id"`
+	UserId       int       `db:"user_id"`
+	ProjectId    int       `db:"project_id"`
+	CredentialId int       `db:"credential_id"`
+	PublicKeyId  int       `db:"public_key_id"`
+	AccessedAt   time.Time `db:"accessed_at"`
+}
+
+type serviceAccountUsageSummaryCore struct {
+	UserId int    `db:"user_id"`
+	Email  string `db:"email"`
+	Key    string `db:"key"`
+	Count  int    `db:"count"`
+	// MAX() loses the column type in sqlite, so the timestamp comes back as text
+	LastAccessedAt string `db:"last_accessed_at"`
+}
+
+type serviceAccountUsageSummary struct {
+	*serviceAccountUsageSummaryCore
+}
+
+func (s serviceAccountUsageSummary) UserId() int {
+	return s.serviceAccountUsageSummaryCore.UserId
+}
+
+func (s serviceAccountUsageSummary) Email() string {
+	return s.serviceAccountUsageSummaryCore.Email
+}
+
+func (s serviceAccountUsageSummary) Key() string {
+	return s.serviceAccountUsageSummaryCore.Key
+}
+
+func (s serviceAccountUsageSummary) Count() int {
+	return s.serviceAccountUsageSummaryCore.Count
+}
+
+func (s serviceAccountUsageSummary) LastAccessedAt() time.Time {
+	return parseSqliteTimestamp(s.serviceAccountUsageSummaryCore.LastAccessedAt)
+}
+
+func RecordServiceAccountUsage(userId, projectId, credentialId, publicKeyId int, dbMap DataMapper) error {
+	return dbMap.Insert(&serviceAccountUsageCore{
+		UserId:       userId,
+		ProjectId:    projectId,
+		CredentialId: credentialId,
+		PublicKeyId:  publicKeyId,
+		AccessedAt:   time.Now().UTC(),
+	})
+}
@@ -2,32 +2,55 @@ package crypto
 
 import (
 	"database/sql"
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 	"gopkg.in/gorp.v1"
+	"strings"
+	"time"
 )
 
+// Unique indexes in schema.sql by the columns sqlite lists when one of them is violated
+var uniqueIndexes = map[string]string{
+	"projects.name, projects.environment":                                              "uniq_p_name_environment",
+	"project_members.project_id, project_members.user_id":                              "uniq_pm_project_id_user_id",
+	"project_credential_keys.project_id, project_credential_keys.key":                  "uniq_pck_project_id_key",
+	"project_credential_values.credential_id, project_credential_values.member_id":     "uniq_pcv_credential_id_member_id",
+	"project_credential_values.credential_id, project_credential_values.public_key_id": "uniq_pcv_credential_id_public_key_id",
+	"user_credentials.public_key_id, user_credentials.key":                             "uniq_uc_public_key_id_key",
+	"api_tokens.token_hash": "uniq_at_token_hash",
+	"message_recipients.message_group_id, message_recipients.user_id": "uniq_mr_message_group_id_user_id",
+}
+
 type DataMapper interface {
 	SelectOne(o interface{}, query string, args ...interface{}) error
 	Select(o interface{}, query string, args ...interface{}) ([]interface{}, error)
 	Insert(o ...interface{}) error
 	Update(o ...interface{}) (int64, error)
 	Delete(o ...interface{}) (int64, error)
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Begin() (Transaction, error)
 	Close()
 }
 
+// Transaction is a DataMapper whose writes only take effect once it is committed
+type Transaction interface {
+	DataMapper
+	Commit() error
+	Rollback() error
+}
+
 type dataMapper struct {
 	*gorp.DbMap
 }
 
-func NewDataMapper() (DataMapper, error) {
-	db, err := sql.Open("sqlite3", SqliteFilePath)
-	if err != nil {
-		return nil, err
-	}
+type transaction struct {
+	*gorp.Transaction
+}
 
-	// In sqlite, we need to explicitly turn on foreign key support for cascades to work
+func NewDataMapper() (DataMapper, error) {
+	// In sqlite, we need to explicitly turn on foreign key support for cascades to work. It's turned on in the DSN
+	// so that every connection in the pool has it, including the ones transactions run on.
 	// SEE: https://stackoverflow.com/questions/5890250/on-delete-cascade-in-sqlite3
-	_, err = db.Exec("PRAGMA foreign_keys = true;")
+	db, err := sql.Open("sqlite3", SqliteFilePath+"?_foreign_keys=1")
 	if err != nil {
 		return nil, err
 	}
@@ -45,6 +68,14 @@ func NewDataMapper() (DataMapper, error) {
 	dbMap.AddTableWithName(projectMemberCore{}, "project_members").SetKeys(true, "Id")
 	dbMap.AddTableWithName(projectCredentialKeyCore{}, "project_credential_keys").SetKeys(true, "Id")
 	dbMap.AddTableWithName(projectCredentialValueCore{}, "project_credential_values").SetKeys(true, "Id")
+	dbMap.AddTableWithName(userCredentialCore{}, "user_credentials").SetKeys(true, "Id")
+	dbMap.AddTableWithName(serviceAccountUsageCore{}, "service_account_usage").SetKeys(true, "Id")
+	dbMap.AddTableWithName(messageGroupCore{}, "message_groups").SetKeys(true, "Id")
+	dbMap.AddTableWithName(messageRecipientCore{}, "message_recipients").SetKeys(true, "Id")
+	dbMap.AddTableWithName(messageAttachmentCore{}, "message_attachments").SetKeys(true, "Id")
+	dbMap.AddTableWithName(keyEventCore{}, "key_events").SetKeys(true, "Id")
+	dbMap.AddTableWithName(hubBroadcastCore{}, "hub_broadcasts").SetKeys(true, "Id")
+	dbMap.AddTableWithName(apiTokenCore{}, "api_tokens").SetKeys(true, "Id")
 
 	return &dataMapper{dbMap}, nil
 }
@@ -52,3 +83,59 @@ func NewDataMapper() (DataMapper, error) {
 func (d *dataMapper) Close() {
 	d.DbMap.Db.Close()
 }
+
+func (d *dataMapper) Begin() (Transaction, error) {
+	tx, err := d.DbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &transaction{tx}, nil
+}
+
+// Begin fails since sqlite doesn't nest transactions
+func (t *transaction) Begin() (Transaction, error) {
+	return nil, NestedTransactionError
+}
+
+// Close does nothing. The transaction ends when it is committed or rolled back, the DataMapper it came from is closed separately.
+func (t *transaction) Close() {
+}
+
+// UniqueConstraint returns the name of the unique index err violates. Columns without an index of their own are
+// returned the way sqlite lists them, e.g. "users.email".
+func UniqueConstraint(err error) (string, bool) {
+	e, ok := err.(sqlite3.Error)
+	if !ok || e.ExtendedCode != sqlite3.ErrConstraintUnique {
+		return "", false
+	}
+	columns := strings.TrimPrefix(e.Error(), "UNIQUE constraint failed: ")
+	if name, ok := uniqueIndexes[columns]; ok {
+		return name, true
+	}
+	return columns, true
+}
+
+// IsForeignKeyViolation reports whether err is sqlite refusing a write that references a missing row
+func IsForeignKeyViolation(err error) bool {
+	e, ok := err.(sqlite3.Error)
+	return ok && e.ExtendedCode == sqlite3.ErrConstraintForeignKey
+}
+
+// IsNotFound reports whether err means the record looked up does not exist
+func IsNotFound(err error) bool {
+	switch err {
+	case sql.ErrNoRows, UserNotFoundError, MessageNotFoundError, AttachmentNotFoundError:
+		return true
+	}
+	return false
+}
+
+// parseSqliteTimestamp parses timestamps that sqlite returns as text, e.g. from aggregate functions
+func parseSqliteTimestamp(s string) time.Time {
+	for _, format := range sqlite3.SQLiteTimestampFormats {
+		if t, err := time.ParseInLocation(format, s, time.UTC); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -9,13 +9,21 @@ import (
 	"time"
 )
 
+const (
+	USER_TYPE_HUMAN   = "human"
+	USER_TYPE_SERVICE = "service"
+)
+
 type userCore struct {
-	Id        int       `db:"id"`
-	Name      string    `db:"name"`
-	Email     string    `db:"email"`
-	Comment   string    `db:"comment"`
-	CreatedAt time.Time `db:"created_at"`
-	UpdatedAt time.Time `db:"updated_at"`
+	Id          int       `db:"id"`
+	Name        string    `db:"name"`
+	Email       string    `db:"email"`
+	Comment     string    `db:"comment"`
+	UserType    string    `db:"user_type"`
+	IsAdmin     bool      `db:"is_admin"`
+	SuspendedAt time.Time `db:"suspended_at"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
 }
 
 type user struct {
@@ -53,11 +61,48 @@ func (u user) ImageURL() string {
 	return fmt.Sprintf("//www.gravatar.com/avatar/%x?s=64&d=wavatar", h.Sum(nil))
 }
 
+func (u user) UserType() string {
+	if u.userCore.UserType == "" {
+		return USER_TYPE_HUMAN
+	}
+	return u.userCore.UserType
+}
+
+func (u user) IsServiceAccount() bool {
+	return u.UserType() == USER_TYPE_SERVICE
+}
+
+func (u user) IsAdmin() bool {
+	return u.userCore.IsAdmin
+}
+
+func (u *user) SetAdmin(isAdmin bool) {
+	u.userCore.IsAdmin = isAdmin
+}
+
+func (u user) SuspendedAt() time.Time {
+	return u.userCore.SuspendedAt
+}
+
+func (u user) IsSuspended() bool {
+	return !u.userCore.SuspendedAt.IsZero()
+}
+
+func (u *user) Suspend() {
+	if u.userCore.SuspendedAt.IsZero() {
+		u.userCore.SuspendedAt = time.Now().UTC()
+	}
+}
+
+func (u *user) Reactivate() {
+	u.userCore.SuspendedAt = time.Time{}
+}
+
 func (u user) PublicKeys(dbMap DataMapper) ([]PublicKey, error) {
 	var ret []PublicKey
 	var keys []*publicKeyCore
-	_, err := dbMap.Select(&keys, "SELECT * FROM public_keys WHERE user_id = ? AND (expires_at = ? OR expires_at > ?) ORDER BY created_at ASC",
-		u.Id(), time.Time{}, time.Now().UTC())
+	_, err := dbMap.Select(&keys, "SELECT * FROM public_keys WHERE user_id = ? AND revoked_at = ? AND (expires_at = ? OR expires_at > ?) ORDER BY created_at ASC",
+		u.Id(), time.Time{}, time.Time{}, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
@@ -70,8 +115,8 @@ func (u user) PublicKeys(dbMap DataMapper) ([]PublicKey, error) {
 func (u user) ActivePublicKeys(dbMap DataMapper) ([]PublicKey, error) {
 	var ret []PublicKey
 	var keys []*publicKeyCore
-	_, err := dbMap.Select(&keys, "SELECT * FROM public_keys WHERE user_id = ? AND activated_at IS NOT NULL AND (expires_at = ? OR expires_at > ?) ORDER BY created_at ASC",
-		u.Id(), time.Time{}, time.Now().UTC())
+	_, err := dbMap.Select(&keys, "SELECT * FROM public_keys WHERE user_id = ? AND activated_at IS NOT NULL AND revoked_at = ? AND (expires_at = ? OR expires_at > ?) ORDER BY created_at ASC",
+		u.Id(), time.Time{}, time.Time{}, time.Now().UTC())
 	if err != nil {
 		return nil, err
 	}
@@ -81,11 +126,23 @@ func (u user) ActivePublicKeys(dbMap DataMapper) ([]PublicKey, error) {
 	return ret, nil
 }
 
-func (u user) EncryptAndSave(sender User, message, subject string, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+func (u user) EncryptAndSave(sender User, message, subject string, opts MessageOptions, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+	// View once messages are grouped so that the ciphers for all of the user's keys can be removed together
+	if opts.ViewOnce {
+		return encryptAndSaveForGroup(sender, []User{&u}, 0, message, subject, opts, dbMap)
+	}
 	kc, err := u.ActivePublicKeys(dbMap)
 	if err != nil {
 		return nil, err
 	}
+	return encryptAndSaveForKeys(sender, kc, message, subject, opts, 0, dbMap)
+}
+
+// encryptAndSaveForKeys encrypts the message to each key concurrently and returns a map of fingerprint to message
+func encryptAndSaveForKeys(sender User, kc []PublicKey, message, subject string, opts MessageOptions, messageGroupId int, dbMap DataMapper) (map[string]EncryptedMessage, error) {
+	if err := opts.validate(); err != nil {
+		return nil, err
+	}
 	ch := make(chan encryptionResult)
 
 	// Loop over the keys and create go routines to encrypt messages per key
@@ -94,7 +151,7 @@ func (u user) EncryptAndSave(sender User, message, subject string, dbMap DataMap
 		go func(sender User, message, subject string, dbMap DataMapper, k PublicKey) {
 
 			er := encryptionResult{key: k.Fingerprint()}
-			encrypted, err := k.EncryptAndSave(sender, message, subject, dbMap)
+			encrypted, err := encryptAndSaveForKey(k, sender, message, subject, opts, messageGroupId, dbMap)
 			if err != nil {
 				er.err = err
 			} else {
@@ -129,6 +186,7 @@ func (u user) EncryptAndSave(sender User, message, subject string, dbMap DataMap
 }
 
 func (u user) Save(dbMap DataMapper) error {
+	u.userCore.UserType = u.UserType()
 	if u.Id() > 0 {
 		_, err := dbMap.Update(u.userCore)
 		return err
@@ -136,6 +194,173 @@ func (u user) Save(dbMap DataMapper) error {
 	return dbMap.Insert(u.userCore)
 }
 
+func (u user) VaultCredentials(publicKeyId int, dbMap DataMapper) ([]UserCredential, error) {
+	var ret []UserCredential
+	var creds []*userCredentialCore
+	_, err := dbMap.Select(&creds, "SELECT * FROM user_credentials WHERE user_id = ? AND public_key_id = ? ORDER BY key ASC", u.Id(), publicKeyId)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range creds {
+		ret = append(ret, &userCredential{c})
+	}
+	return ret, nil
+}
+
+func (u user) GetVaultCredential(key string, publicKeyId int, dbMap DataMapper) (UserCredential, error) {
+	uc, err := FindUserCredentialForPublicKey(key, publicKeyId, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	// Keys are unique, but make sure the credential really belongs to this user
+	if uc.UserId() != u.Id() {
+		return nil, sql.ErrNoRows
+	}
+	return uc, nil
+}
+
+func (u user) SetVaultCredential(key, value string, dbMap DataMapper) error {
+	if int64(len(value)) > MaxCredentialSize {
+		return CredentialTooLargeError
+	}
+	keys, err := u.ActivePublicKeys(dbMap)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+	if len(keys) == 0 {
+		return NoActivePublicKeysError
+	}
+	// Encrypt the value to each of the user's own active keys
+	for _, k := range keys {
+		cipher, binary, err := encryptCredential(k, value)
+		if err != nil {
+			return err
+		}
+		uc, err := u.GetVaultCredential(key, k.Id(), dbMap)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		if err == sql.ErrNoRows {
+			uc = NewUserCredential(u.Id(), k.Id(), key, cipher, binary)
+		} else {
+			uc.SetCipher(cipher, binary)
+		}
+		if err := uc.Save(dbMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u user) RemoveVaultCredential(key string, dbMap DataMapper) error {
+	var creds []*userCredentialCore
+	_, err := dbMap.Select(&creds, "SELECT * FROM user_credentials WHERE user_id = ? AND key = ?", u.Id(), key)
+	if err != nil {
+		return err
+	}
+	for _, c := range creds {
+		if err := (&userCredential{c}).Delete(dbMap); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u user) DecryptableCredentials(dbMap DataMapper) ([]ExposedCredential, error) {
+	var ret []ExposedCredential
+	var creds []*exposedCredentialCore
+	_, err := dbMap.Select(&creds, `SELECT DISTINCT p.id AS project_id, p.name AS project_name, p.environment AS environment, pck.key AS key
+		FROM project_credential_values pcv
+		INNER JOIN public_keys k ON k.id = pcv.public_key_id
+		INNER JOIN project_credential_keys pck ON pck.id = pcv.credential_id
+		INNER JOIN projects p ON p.id = pck.project_id
+		WHERE k.user_id = ?
+		ORDER BY p.name ASC, p.environment ASC, pck.key ASC`, u.Id())
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range creds {
+		ret = append(ret, &exposedCredential{c})
+	}
+	return ret, nil
+}
+
+// Offboard removes the user from their projects, revokes their keys and suspends them in a single transaction.
+// It returns the credentials the user could decrypt. The only admin of a project can't be offboarded, since nobody
+// would be left to manage the project.
+func (u *user) Offboard(dbMap DataMapper) ([]ExposedCredential, error) {
+	tx, err := dbMap.Begin()
+	if err != nil {
+		return nil, err
+	}
+	report, err := u.offboard(tx)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (u *user) offboard(dbMap DataMapper) ([]ExposedCredential, error) {
+	// Build the report first. The credential values go away with the memberships.
+	report, err := u.DecryptableCredentials(dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	projects, err := FindProjectsForUser(u.Id(), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range projects {
+		if p.HasAdminWithUserId(u.Id(), dbMap) && !p.HasAdminOtherThanUserId(u.Id(), dbMap) {
+			return nil, LastProjectAdminError
+		}
+	}
+	for _, p := range projects {
+		if err = p.RemoveMember(u.Id(), dbMap); err != nil {
+			return nil, err
+		}
+	}
+
+	keys, err := u.PublicKeys(dbMap)
+	if err != nil {
+		return nil, err
+	}
+	for _, k := range keys {
+		k.Revoke()
+		if err = k.Save(dbMap); err != nil {
+			return nil, err
+		}
+	}
+
+	u.Suspend()
+	if err = u.Save(dbMap); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (u user) Delete(dbMap DataMapper) error {
+	_, err := dbMap.Delete(u.userCore)
+	return err
+}
+
+func NewServiceAccount(email, name, comment string) User {
+	currentTime := time.Now().UTC()
+	return &user{&userCore{
+		Name:      name,
+		Email:     email,
+		Comment:   comment,
+		UserType:  USER_TYPE_SERVICE,
+		CreatedAt: currentTime,
+		UpdatedAt: currentTime,
+	}}
+}
+
 func FindUserWithId(id int, dbMap DataMapper) (User, error) {
 	uc := &userCore{Id: id}
 	err := dbMap.SelectOne(uc, "SELECT * FROM users WHERE id = ?", uc.Id)
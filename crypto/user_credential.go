@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"time"
+)
+
+type userCredentialCore struct {
+	Id          int       `db:"id"`
+	UserId      int       `db:"user_id"`
+	PublicKeyId int       `db:"public_key_id"`
+	Key         string    `db:"key"`
+	Cipher      []byte    `db:"cipher"`
+	Binary      bool      `db:"binary"`
+	CreatedAt   time.Time `db:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at"`
+}
+
+type userCredential struct {
+	*userCredentialCore
+}
+
+func (uc userCredential) Id() int {
+	return uc.userCredentialCore.Id
+}
+
+func (uc userCredential) UserId() int {
+	return uc.userCredentialCore.UserId
+}
+
+func (uc userCredential) PublicKeyId() int {
+	return uc.userCredentialCore.PublicKeyId
+}
+
+func (uc userCredential) Key() string {
+	return uc.userCredentialCore.Key
+}
+
+func (uc userCredential) Cipher() []byte {
+	return uc.userCredentialCore.Cipher
+}
+
+// Binary reports if the value was base64 encoded before it was encrypted
+func (uc userCredential) Binary() bool {
+	return uc.userCredentialCore.Binary
+}
+
+func (uc *userCredential) SetCipher(cipher []byte, binary bool) {
+	uc.userCredentialCore.Cipher = cipher
+	uc.userCredentialCore.Binary = binary
+	uc.userCredentialCore.UpdatedAt = time.Now().UTC()
+}
+
+func (uc userCredential) CreatedAt() time.Time {
+	return uc.userCredentialCore.CreatedAt
+}
+
+func (uc userCredential) UpdatedAt() time.Time {
+	return uc.userCredentialCore.UpdatedAt
+}
+
+func (uc userCredential) Save(dbMap DataMapper) error {
+	if uc.Id() > 0 {
+		_, err := dbMap.Update(uc.userCredentialCore)
+		return err
+	}
+	return dbMap.Insert(uc.userCredentialCore)
+}
+
+func (uc userCredential) Delete(dbMap DataMapper) error {
+	_, err := dbMap.Delete(uc.userCredentialCore)
+	return err
+}
+
+func NewUserCredential(userId, publicKeyId int, key string, cipher []byte, binary bool) UserCredential {
+	currentTime := time.Now().UTC()
+	return &userCredential{&userCredentialCore{
+		UserId:      userId,
+		PublicKeyId: publicKeyId,
+		Key:         key,
+		Cipher:      cipher,
+		Binary:      binary,
+		CreatedAt:   currentTime,
+		UpdatedAt:   currentTime,
+	}}
+}
+
+func FindUserCredentialForPublicKey(key string, publicKeyId int, dbMap DataMapper) (UserCredential, error) {
+	ucc := &userCredentialCore{Key: key, PublicKeyId: publicKeyId}
+	err := dbMap.SelectOne(ucc, "SELECT * FROM user_credentials WHERE key = ? AND public_key_id = ?", ucc.Key, ucc.PublicKeyId)
+	if err != nil {
+		return nil, err
+	}
+	return &userCredential{ucc}, nil
+}
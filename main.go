@@ -1,24 +1,39 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"github.com/rajivnavada/cryptzd/crypto"
 	"github.com/rajivnavada/cryptzd/mail"
 	"github.com/rajivnavada/cryptzd/web"
+	"google.golang.org/grpc/credentials"
+	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 var (
 	host                    = flag.String("host", "127.0.0.1", "HTTP service host")
 	port                    = flag.String("port", "8000", "HTTP port at which the service will run")
+	grpcPort                = flag.String("grpcPort", "8001", "Port at which the gRPC service will run")
 	certFile                = flag.String("cert", "cert.pem", "Path to cert.pem")
 	keyFile                 = flag.String("key", "key.pem", "Path to key.pem")
 	sqliteFilePath          = flag.String("db", "/usr/local/var/db/cryptz/cryptz.db", "Path to sqllite db file.")
 	appEmail                = flag.String("appEmail", "", "Email address to use for sender for this app")
 	appEmailPasswordEnvName = flag.String("appPasswordEnvName", "MAILPASS", "Name of the environment variable that contains the password for this app email sender")
 	debug                   = flag.Bool("debug", false, "Turn on debug mode")
+	adminEmail              = flag.String("admin", "", "Email address of a user to bootstrap as a server administrator")
+	purgeInterval           = flag.Duration("purgeInterval", time.Minute, "How often expired messages are deleted")
+	encryptSubjects         = flag.Bool("encryptSubjects", false, "Encrypt the subject of every message along with the body")
+	maxAttachmentSize       = flag.Int64("maxAttachmentSize", 5<<20, "Maximum total size in bytes of the files attached to a message")
+	maxCredentialSize       = flag.Int64("maxCredentialSize", 1<<20, "Maximum size in bytes of a credential value")
+	broadcastInterval       = flag.Duration("broadcastInterval", 0, "When running several instances against a shared database, how often each one picks up the pushes made by the others. 0 disables it.")
+	apiTokenTTL             = flag.Duration("apiTokenTTL", 30*24*time.Hour, "How long an API token can be used after it is issued")
+	shutdownTimeout         = flag.Duration("shutdownTimeout", 30*time.Second, "How long to wait for in-flight requests and operations when shutting down")
 )
 
 func main() {
@@ -26,11 +41,38 @@ func main() {
 
 	// Init services
 	crypto.InitService(*sqliteFilePath, *debug)
+	crypto.EncryptSubjects = *encryptSubjects
+	crypto.MaxAttachmentSize = *maxAttachmentSize
+	crypto.MaxCredentialSize = *maxCredentialSize
+	crypto.APITokenTTL = *apiTokenTTL
 	mail.InitService(*appEmail, os.Getenv(*appEmailPasswordEnvName))
 
+	// bring databases created by an older schema.sql up to date
+	if err := crypto.MigrateDatabase(); err != nil {
+		panic(err)
+	}
+
+	if *adminEmail != "" {
+		if _, err := crypto.BootstrapAdmin(*adminEmail); err != nil {
+			panic(err)
+		}
+	}
+
 	// start the connection hub for websocket stuff
 	go web.H.Run()
-	defer web.H.Close()
+
+	// share pushes with the other instances
+	if *broadcastInterval > 0 {
+		b, err := web.NewDatabaseBroadcaster(*broadcastInterval)
+		if err != nil {
+			panic(err)
+		}
+		web.UseBroadcaster(b)
+		defer b.Close()
+	}
+
+	// start deleting messages whose time to live has run out
+	go web.RunMessagePurger(*purgeInterval)
 
 	router := web.Router()
 	addr := *host + ":" + *port
@@ -43,7 +85,69 @@ func main() {
 		Addr:    addr,
 		Handler: router,
 	}
-	if err := server.ListenAndServeTLS(*certFile, *keyFile); err != nil {
+	go func() {
+		if err := server.ListenAndServeTLS(*certFile, *keyFile); err != nil && err != http.ErrServerClosed {
+			panic(err)
+		}
+	}()
+
+	// the gRPC service uses the same certificate
+	creds, err := credentials.NewServerTLSFromFile(*certFile, *keyFile)
+	if err != nil {
+		panic(err)
+	}
+	grpcServer := web.NewGRPCServer(creds)
+	grpcAddr := *host + ":" + *grpcPort
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
 		panic(err)
 	}
+	if *debug {
+		fmt.Printf("Starting gRPC server at: %s\n", grpcAddr)
+	}
+	// Serve returns an error once the server is stopped as well
+	grpcStopping := make(chan struct{})
+	go func() {
+		if err := grpcServer.Serve(lis); err != nil {
+			select {
+			case <-grpcStopping:
+			default:
+				panic(err)
+			}
+		}
+	}()
+
+	// Wait for a signal to shut down
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
+	<-sig
+
+	if *debug {
+		fmt.Println("Shutting down http server")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting connections and wait for regular requests to finish.
+	// Websockets are hijacked so the server doesn't wait for them, the hub drains those.
+	if err := server.Shutdown(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error shutting down http server:", err)
+	}
+	if err := web.H.Shutdown(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Error draining websocket connections:", err)
+	}
+
+	// The hub ended the event streams, so only unary calls are left to wait for
+	close(grpcStopping)
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		grpcServer.Stop()
+	}
 }
@@ -3,6 +3,9 @@ CREATE TABLE IF NOT EXISTS "users" (
     "name" varchar(255),
     "email" varchar(255) not null unique,
     "comment" varchar(255),
+    "user_type" varchar(255) not null DEFAULT "human",
+    "is_admin" boolean not null DEFAULT 0,
+    "suspended_at" datetime,
     "created_at" datetime not null,
     "updated_at" datetime not null
 );
@@ -16,6 +19,8 @@ CREATE TABLE IF NOT EXISTS "public_keys" (
     "updated_at" datetime not null,
     "activated_at" datetime,
     "expires_at" datetime not null,
+    "revoked_at" datetime,
+    "last_event_id" integer not null DEFAULT 0,
     FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
 );
 
@@ -23,14 +28,24 @@ CREATE TABLE IF NOT EXISTS "encrypted_messages" (
     "id" integer not null primary key autoincrement,
     "sender_id" integer not null,
     "public_key_id" integer not null,
+    "message_group_id" integer not null DEFAULT 0,
+    "parent_id" integer not null DEFAULT 0,
+    "thread_id" integer not null DEFAULT 0,
     "subject" varchar(255),
     "cipher" blob not null,
+    "read_at" datetime,
+    "expires_at" datetime,
+    "view_once" boolean not null DEFAULT 0,
+    "subject_encrypted" boolean not null DEFAULT 0,
     "created_at" datetime not null,
     "updated_at" datetime not null,
     FOREIGN KEY("sender_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
     FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
 );
 
+CREATE INDEX IF NOT EXISTS idx_em_public_key_id_created_at ON encrypted_messages(public_key_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_em_thread_id ON encrypted_messages(thread_id);
+
 CREATE TABLE IF NOT EXISTS "projects" (
     "id" integer not null primary key autoincrement,
     "name" varchar(255),
@@ -72,6 +87,7 @@ CREATE TABLE IF NOT EXISTS "project_credential_values" (
     "member_id" integer not null,
     "public_key_id" integer not null,
     "cipher" blob not null,
+    "binary" boolean not null DEFAULT 0,
     "created_at" datetime not null,
     "updated_at" datetime not null,
     "expires_at" datetime not null,
@@ -83,3 +99,100 @@ CREATE TABLE IF NOT EXISTS "project_credential_values" (
 CREATE UNIQUE INDEX IF NOT EXISTS uniq_pcv_credential_id_member_id ON project_credential_values(credential_id, member_id);
 CREATE UNIQUE INDEX IF NOT EXISTS uniq_pcv_credential_id_public_key_id ON project_credential_values(credential_id, public_key_id);
 
+CREATE TABLE IF NOT EXISTS "user_credentials" (
+    "id" integer not null primary key autoincrement,
+    "user_id" integer not null,
+    "public_key_id" integer not null,
+    "key" varchar(255) not null,
+    "cipher" blob not null,
+    "binary" boolean not null DEFAULT 0,
+    "created_at" datetime not null,
+    "updated_at" datetime not null,
+    FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_uc_public_key_id_key ON user_credentials(public_key_id, key);
+
+CREATE TABLE IF NOT EXISTS "service_account_usage" (
+    "id" integer not null primary key autoincrement,
+    "user_id" integer not null,
+    "project_id" integer not null,
+    "credential_id" integer not null,
+    "public_key_id" integer not null,
+    "accessed_at" datetime not null,
+    FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY("project_id") REFERENCES projects(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY("credential_id") REFERENCES project_credential_keys(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_sau_project_id ON service_account_usage(project_id);
+
+CREATE TABLE IF NOT EXISTS "message_groups" (
+    "id" integer not null primary key autoincrement,
+    "sender_id" integer not null,
+    "project_id" integer not null DEFAULT 0,
+    "subject" varchar(255),
+    "created_at" datetime not null,
+    FOREIGN KEY("sender_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS "message_recipients" (
+    "id" integer not null primary key autoincrement,
+    "message_group_id" integer not null,
+    "user_id" integer not null,
+    FOREIGN KEY("message_group_id") REFERENCES message_groups(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    FOREIGN KEY("user_id") REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_mr_message_group_id_user_id ON message_recipients(message_group_id, user_id);
+
+CREATE TABLE IF NOT EXISTS "message_attachments" (
+    "id" integer not null primary key autoincrement,
+    "message_id" integer not null,
+    "filename" varchar(255) not null,
+    "content_type" varchar(255),
+    "size" integer not null DEFAULT 0,
+    "cipher" blob not null,
+    "created_at" datetime not null,
+    FOREIGN KEY("message_id") REFERENCES encrypted_messages(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ma_message_id ON message_attachments(message_id);
+
+CREATE TABLE IF NOT EXISTS "key_events" (
+    "id" integer not null primary key autoincrement,
+    "public_key_id" integer not null,
+    "message_id" integer not null DEFAULT 0,
+    "text" text,
+    "created_at" datetime not null,
+    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_ke_public_key_id_id ON key_events(public_key_id, id);
+
+CREATE TABLE IF NOT EXISTS "hub_broadcasts" (
+    "id" integer not null primary key autoincrement,
+    "origin" varchar(64) not null,
+    "payload" blob not null,
+    "created_at" datetime not null
+);
+
+CREATE INDEX IF NOT EXISTS idx_hb_created_at ON hub_broadcasts(created_at);
+
+CREATE TABLE IF NOT EXISTS "api_tokens" (
+    "id" integer not null primary key autoincrement,
+    "public_key_id" integer not null,
+    "token_hash" varchar(64) not null,
+    "created_at" datetime not null,
+    "expires_at" datetime not null,
+    "last_used_at" datetime not null,
+    FOREIGN KEY("public_key_id") REFERENCES public_keys(id) ON UPDATE CASCADE ON DELETE CASCADE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS uniq_at_token_hash ON api_tokens(token_hash);
+CREATE INDEX IF NOT EXISTS idx_at_expires_at ON api_tokens(expires_at);
+
+-- Number of migrations in crypto/migrations.go. Databases created from this file need none of them.
+PRAGMA user_version = 14;
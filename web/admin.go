@@ -0,0 +1,272 @@
+package web
+
+import (
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+const (
+	AdminURL = "/admin"
+)
+
+var (
+	NotAnAdminError       = errors.New("You need to be a server administrator to perform this operation.")
+	CannotTargetSelfError = errors.New("Administrators cannot suspend or delete their own account.")
+)
+
+type adminUserRow struct {
+	User          crypto.User
+	Keys          []crypto.PublicKey
+	IsCurrentUser bool
+}
+
+// findAdmin returns the user with the given id if they are an active server administrator
+func findAdmin(uid int, dbMap crypto.DataMapper) (crypto.User, error) {
+	u, err := crypto.FindUserWithId(uid, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	if u.Id() == 0 || !u.IsAdmin() || u.IsSuspended() {
+		return nil, NotAnAdminError
+	}
+	return u, nil
+}
+
+func findTargetUser(admin crypto.User, targetId int, dbMap crypto.DataMapper) (crypto.User, error) {
+	if admin.Id() == targetId {
+		return nil, CannotTargetSelfError
+	}
+	u, err := crypto.FindUserWithId(targetId, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	if u.Id() == 0 {
+		return nil, crypto.UserNotFoundError
+	}
+	return u, nil
+}
+
+func suspendUser(admin crypto.User, targetId int, dbMap crypto.DataMapper) error {
+	u, err := findTargetUser(admin, targetId, dbMap)
+	if err != nil {
+		return err
+	}
+	u.Suspend()
+	if err = u.Save(dbMap); err != nil {
+		return err
+	}
+	// Kick out any connections the user still has open
+	broadcast(envelope{Disconnect: &disconnectRequest{UserId: userId(u.Id())}})
+	return nil
+}
+
+func reactivateUser(admin crypto.User, targetId int, dbMap crypto.DataMapper) error {
+	u, err := findTargetUser(admin, targetId, dbMap)
+	if err != nil {
+		return err
+	}
+	u.Reactivate()
+	return u.Save(dbMap)
+}
+
+func deleteUser(admin crypto.User, targetId int, dbMap crypto.DataMapper) error {
+	u, err := findTargetUser(admin, targetId, dbMap)
+	if err != nil {
+		return err
+	}
+	// Keys, messages and project memberships are removed by the cascading deletes
+	if err = u.Delete(dbMap); err != nil {
+		return err
+	}
+	broadcast(envelope{Disconnect: &disconnectRequest{UserId: userId(u.Id())}})
+	return nil
+}
+
+func offboardUser(admin crypto.User, targetId int, dbMap crypto.DataMapper) ([]crypto.ExposedCredential, error) {
+	u, err := findTargetUser(admin, targetId, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	// Memberships are gathered first so the projects can be told the user left
+	projects, err := crypto.FindProjectsForUser(u.Id(), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	var members []crypto.ProjectMember
+	for _, p := range projects {
+		m, err := crypto.FindProjectMemberWithUserId(u.Id(), p.Id(), dbMap)
+		if err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+
+	report, err := u.Offboard(dbMap)
+	if err != nil {
+		return nil, err
+	}
+	broadcast(envelope{Disconnect: &disconnectRequest{UserId: userId(u.Id())}})
+	for i, p := range projects {
+		publishMemberEvent(p, pb.ProjectEvent_MEMBER_REMOVED, members[i], dbMap)
+	}
+	return report, nil
+}
+
+func deleteKey(admin crypto.User, keyId int, dbMap crypto.DataMapper) error {
+	k, err := crypto.FindKeyWithId(keyId, dbMap)
+	if err != nil {
+		return err
+	}
+	if k.UserId() == admin.Id() {
+		return CannotTargetSelfError
+	}
+	if err = k.Delete(dbMap); err != nil {
+		return err
+	}
+	broadcast(envelope{Disconnect: &disconnectRequest{Fingerprint: fingerprint(k.Fingerprint())}})
+	return nil
+}
+
+// mustBeAdmin works like mustBeAuthenticated but also requires the session user to be a server administrator
+func mustBeAdmin(w http.ResponseWriter, r *http.Request, dbMap crypto.DataMapper) crypto.User {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return nil
+	}
+	admin, err := findAdmin(sess.UserId, dbMap)
+	if err != nil {
+		logError(err, "Non admin user tried to access "+r.URL.String())
+		http.Error(w, NotAnAdminError.Error(), http.StatusForbidden)
+		return nil
+	}
+	return admin
+}
+
+func GetAdmin(w http.ResponseWriter, r *http.Request) {
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	admin := mustBeAdmin(w, r, dbMap)
+	if admin == nil {
+		return
+	}
+
+	uc, err := crypto.FindAllUsers(dbMap)
+	if !assertErrorIsNil(w, err, "Error extracting all users") {
+		return
+	}
+
+	var rows []adminUserRow
+	for _, u := range uc {
+		keys, err := u.PublicKeys(dbMap)
+		if !assertErrorIsNil(w, err, "Error extracting keys for user "+u.Email()) {
+			return
+		}
+		rows = append(rows, adminUserRow{
+			User:          u,
+			Keys:          keys,
+			IsCurrentUser: u.Id() == admin.Id(),
+		})
+	}
+
+	templateDefs := newTemplateArgs()
+	templateDefs.Extensions = &struct {
+		Users    []adminUserRow
+		AdminURL string
+		Error    string
+	}{
+		Users:    rows,
+		AdminURL: AdminURL,
+		Error:    r.URL.Query().Get("error"),
+	}
+
+	if err := adminTemplate.Execute(w, templateDefs); err != nil {
+		panic(err)
+	}
+}
+
+// handleAdminAction runs the action on the id in the path as the administrator of the session. Once the action succeeds,
+// done writes the response. A nil done redirects back to the admin page.
+func handleAdminAction(w http.ResponseWriter, r *http.Request, varName string, action func(crypto.User, int, crypto.DataMapper) error, done func()) {
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	admin := mustBeAdmin(w, r, dbMap)
+	if admin == nil {
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)[varName])
+	if err != nil {
+		http.Error(w, "Invalid "+varName, http.StatusBadRequest)
+		return
+	}
+
+	if err = action(admin, id, dbMap); err != nil {
+		logError(err, "Error handling "+r.URL.String())
+		http.Redirect(w, r, AdminURL+"?"+url.Values{"error": {err.Error()}}.Encode(), http.StatusSeeOther)
+		return
+	}
+	if done != nil {
+		done()
+		return
+	}
+	http.Redirect(w, r, AdminURL, http.StatusSeeOther)
+}
+
+func PostAdminSuspendUser(w http.ResponseWriter, r *http.Request) {
+	handleAdminAction(w, r, "userId", suspendUser, nil)
+}
+
+func PostAdminReactivateUser(w http.ResponseWriter, r *http.Request) {
+	handleAdminAction(w, r, "userId", reactivateUser, nil)
+}
+
+func PostAdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+	handleAdminAction(w, r, "userId", deleteUser, nil)
+}
+
+func PostAdminOffboardUser(w http.ResponseWriter, r *http.Request) {
+	var u crypto.User
+	var report []crypto.ExposedCredential
+	offboard := func(admin crypto.User, id int, dbMap crypto.DataMapper) (err error) {
+		if u, err = crypto.FindUserWithId(id, dbMap); err != nil {
+			return err
+		}
+		report, err = offboardUser(admin, id, dbMap)
+		return err
+	}
+
+	// The report of what the user could decrypt is shown instead of going back to the admin page
+	handleAdminAction(w, r, "userId", offboard, func() {
+		templateDefs := newTemplateArgs()
+		templateDefs.Extensions = &struct {
+			User        crypto.User
+			Credentials []crypto.ExposedCredential
+			AdminURL    string
+		}{
+			User:        u,
+			Credentials: report,
+			AdminURL:    AdminURL,
+		}
+
+		if err := offboardTemplate.Execute(w, templateDefs); err != nil {
+			panic(err)
+		}
+	})
+}
+
+func PostAdminDeleteKey(w http.ResponseWriter, r *http.Request) {
+	handleAdminAction(w, r, "keyId", deleteKey, nil)
+}
@@ -0,0 +1,502 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// The HTTP API performs the same operations as the websocket, for clients that can't speak protobuf.
+// Requests are turned into a pb.Operation and performed by a connection acting for the key the API token was issued to,
+// so both go through the same authorization and crypto code.
+
+const (
+	APIURL = "/api/v1"
+
+	apiTokenPrefix = "Bearer "
+)
+
+// Largest request bodies read by the API. Binary values are base64 encoded in JSON, so they take up more
+// room than over the websocket. Only messages can carry attachments.
+var (
+	maxAPIRequestSize           = int64(maxMessageSize)
+	maxAPICredentialRequestSize = maxMessageSize + crypto.MaxCredentialSize*2
+	maxAPIMessageRequestSize    = maxMessageSize + crypto.MaxAttachmentSize*2
+)
+
+var (
+	MissingAPITokenError   = errors.New("Request is missing an API token. Send one in the Authorization header as: Bearer <token>")
+	InvalidAPIRequestError = errors.New("Request body is not valid JSON for this endpoint.")
+	InvalidAPIPathError    = errors.New("Request path contains an invalid id.")
+)
+
+// HTTP status codes of failed operations
+var apiStatusCodes = map[pb.Response_ErrorCode]int{
+	pb.Response_INTERNAL:            http.StatusInternalServerError,
+	pb.Response_INVALID_ARGUMENT:    http.StatusBadRequest,
+	pb.Response_NOT_FOUND:           http.StatusNotFound,
+	pb.Response_NO_ACCESS:           http.StatusForbidden,
+	pb.Response_ALREADY_EXISTS:      http.StatusConflict,
+	pb.Response_FAILED_PRECONDITION: http.StatusConflict,
+	pb.Response_TOO_LARGE:           http.StatusRequestEntityTooLarge,
+	pb.Response_UNSUPPORTED:         http.StatusNotImplemented,
+	pb.Response_TOO_MANY_OPERATIONS: http.StatusTooManyRequests,
+	pb.Response_TIMED_OUT:           http.StatusGatewayTimeout,
+	pb.Response_SHUTTING_DOWN:       http.StatusServiceUnavailable,
+	pb.Response_UPGRADE_REQUIRED:    http.StatusUpgradeRequired,
+	pb.Response_SUSPENDED:           http.StatusForbidden,
+	pb.Response_UNAUTHENTICATED:     http.StatusUnauthorized,
+}
+
+// apiResponse is the body of every API response. Result holds whatever the endpoint returns and is left out on errors.
+type apiResponse struct {
+	Info         string            `json:"info,omitempty"`
+	Error        string            `json:"error,omitempty"`
+	ErrorCode    string            `json:"errorCode,omitempty"`
+	ErrorDetails []*pb.ErrorDetail `json:"errorDetails,omitempty"`
+	Result       interface{}       `json:"result,omitempty"`
+}
+
+// apiHandler handles a request authenticated with an API token
+type apiHandler func(w http.ResponseWriter, r *http.Request, c *connection)
+
+func writeAPIResponse(w http.ResponseWriter, status int, body *apiResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logError(err, "An error occured when writing JSON to response writer")
+	}
+}
+
+// writeAPIError writes the error with the code an operation failing with it would get
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	result := errorResponse(0, err)
+	writeAPIResponse(w, status, &apiResponse{
+		Error:        result.Error,
+		ErrorCode:    result.ErrorCode.String(),
+		ErrorDetails: result.ErrorDetails,
+	})
+}
+
+// apiToken returns the token in the Authorization header
+func apiToken(r *http.Request) string {
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, apiTokenPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(h, apiTokenPrefix))
+}
+
+// withAPIToken authenticates the request before handing it to h along with a connection acting for the key of the token
+func withAPIToken(h apiHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := apiToken(r)
+		if token == "" {
+			writeAPIError(w, http.StatusUnauthorized, MissingAPITokenError)
+			return
+		}
+		c, status, err := connectionForToken(token)
+		if err != nil {
+			writeAPIError(w, status, err)
+			return
+		}
+		h(w, r, c)
+	}
+}
+
+// connectionForToken returns a connection acting for the key the API token was issued to.
+// When the token can't be used it returns why along with the HTTP status that goes with it.
+func connectionForToken(token string) (*connection, int, error) {
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		logError(err, "Error creating instance of crypto.DataMapper")
+		return nil, http.StatusInternalServerError, err
+	}
+	defer dbMap.Close()
+
+	t, err := crypto.FindAPIToken(token, dbMap)
+	if err == crypto.APITokenNotFoundError {
+		return nil, http.StatusUnauthorized, err
+	} else if err != nil {
+		logError(err, "Error finding API token")
+		return nil, http.StatusInternalServerError, err
+	}
+
+	key, err := crypto.FindKeyWithId(t.PublicKeyId(), dbMap)
+	if err != nil {
+		logError(err, "Error finding key of API token")
+		return nil, http.StatusUnauthorized, crypto.APITokenNotFoundError
+	}
+	u := key.User(dbMap)
+	if u == nil {
+		return nil, http.StatusUnauthorized, crypto.APITokenNotFoundError
+	}
+	// Checked on every request so revoking the key or suspending the user cuts off its tokens right away
+	if err := checkKey(key, u); err != nil {
+		return nil, http.StatusForbidden, err
+	}
+
+	t.Touch()
+	if err := t.Save(dbMap); err != nil {
+		logError(err, "Error recording use of API token")
+	}
+
+	// The API only ever performs operations on the connection, it never has a websocket behind it
+	c := newConnection(nil, userId(u.Id()), publicKeyId(key.Id()), fingerprint(key.Fingerprint()), true, u.IsServiceAccount())
+	c.apiToken = token
+	return c, http.StatusOK, nil
+}
+
+// decodeAPIRequest decodes the JSON body of the request into v, reading at most limit bytes.
+// It writes an error response and returns false otherwise.
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, v interface{}, limit int64) bool {
+	body := http.MaxBytesReader(w, r.Body, limit)
+	if err := json.NewDecoder(body).Decode(v); err != nil && err != io.EOF {
+		writeAPIError(w, http.StatusBadRequest, InvalidAPIRequestError)
+		return false
+	}
+	return true
+}
+
+// apiPathId parses the id in the path. It writes an error response and returns false otherwise.
+func apiPathId(w http.ResponseWriter, r *http.Request, name string) (int32, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)[name])
+	if err != nil || id <= 0 {
+		writeAPIError(w, http.StatusBadRequest, InvalidAPIPathError)
+		return 0, false
+	}
+	return int32(id), true
+}
+
+// performAPIOperation performs the operation and writes its result. pick chooses what goes in the result of a successful response.
+func (c *connection) performAPIOperation(w http.ResponseWriter, op *pb.Operation, pick func(*pb.Response) interface{}) {
+	if err := H.startOperation(); err != nil {
+		writeAPIError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	result := c.perform(op)
+	H.inFlight.Done()
+
+	if result.Status != pb.Response_SUCCESS {
+		status, ok := apiStatusCodes[result.ErrorCode]
+		if !ok {
+			status = http.StatusInternalServerError
+		}
+		writeAPIResponse(w, status, &apiResponse{
+			Error:        result.Error,
+			ErrorCode:    result.ErrorCode.String(),
+			ErrorDetails: result.ErrorDetails,
+		})
+		return
+	}
+
+	body := &apiResponse{Info: result.Info}
+	if pick != nil {
+		body.Result = pick(result)
+	}
+	writeAPIResponse(w, http.StatusOK, body)
+}
+
+// PostAPIToken issues a token for the key with the fingerprint in the request. The token comes back encrypted to the key,
+// so only whoever holds the private key can use it.
+func PostAPIToken(w http.ResponseWriter, r *http.Request) {
+	req := &struct {
+		Fingerprint string `json:"fingerprint"`
+	}{}
+	if !decodeAPIRequest(w, r, req, maxAPIRequestSize) {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		logError(err, "Error creating instance of crypto.DataMapper")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer dbMap.Close()
+
+	key, err := crypto.FindPublicKeyWithFingerprint(strings.TrimSpace(req.Fingerprint), dbMap)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, crypto.UserNotFoundError)
+		return
+	}
+	u := key.User(dbMap)
+	if u == nil {
+		writeAPIError(w, http.StatusNotFound, crypto.UserNotFoundError)
+		return
+	}
+	if err := checkKey(key, u); err != nil {
+		writeAPIError(w, http.StatusForbidden, err)
+		return
+	}
+
+	t, token, err := crypto.NewAPIToken(key.Id(), dbMap)
+	if err != nil {
+		logError(err, "Error creating API token")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	cipher, err := key.Encrypt(token)
+	if err != nil {
+		logError(err, "Error encrypting API token")
+		t.Delete(dbMap)
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeAPIResponse(w, http.StatusOK, &apiResponse{
+		Info: "Decrypt the cipher to get your API token",
+		Result: &struct {
+			Cipher    string `json:"cipher"`
+			ExpiresAt int64  `json:"expiresAt"`
+		}{cipher, t.ExpiresAt().Unix()},
+	})
+}
+
+// GetAPIDocument serves the OpenAPI document describing the API
+func GetAPIDocument(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, openAPIDocument)
+}
+
+func deleteAPIToken(w http.ResponseWriter, r *http.Request, c *connection) {
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		logError(err, "Error creating instance of crypto.DataMapper")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer dbMap.Close()
+
+	t, err := crypto.FindAPIToken(apiToken(r), dbMap)
+	if err == nil {
+		err = t.Delete(dbMap)
+	}
+	if err != nil {
+		logError(err, "Error revoking API token")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &apiResponse{Info: "Revoked API token"})
+}
+
+func getAPIKeys(w http.ResponseWriter, r *http.Request, c *connection) {
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		logError(err, "Error creating instance of crypto.DataMapper")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	defer dbMap.Close()
+
+	u, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		logError(err, "Error finding user for API request")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	keys, err := u.PublicKeys(dbMap)
+	if err != nil {
+		logError(err, "Error listing keys for API request")
+		writeAPIError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeAPIResponse(w, http.StatusOK, &apiResponse{Result: newPbUser(u, keys).Keys})
+}
+
+func getAPIProjects(w http.ResponseWriter, r *http.Request, c *connection) {
+	op := &pb.ProjectOperation{Command: pb.ProjectOperation_LIST}
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, func(res *pb.Response) interface{} {
+		return res.ProjectOpResponse.Projects
+	})
+}
+
+func postAPIProject(w http.ResponseWriter, r *http.Request, c *connection) {
+	op := &pb.ProjectOperation{}
+	if !decodeAPIRequest(w, r, op, maxAPIRequestSize) {
+		return
+	}
+	op.Command = pb.ProjectOperation_CREATE
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, func(res *pb.Response) interface{} {
+		return res.ProjectOpResponse.Project
+	})
+}
+
+func postAPIMember(w http.ResponseWriter, r *http.Request, c *connection) {
+	projectId, ok := apiPathId(w, r, "projectId")
+	if !ok {
+		return
+	}
+	op := &pb.ProjectOperation{}
+	if !decodeAPIRequest(w, r, op, maxAPIRequestSize) {
+		return
+	}
+	op.Command = pb.ProjectOperation_ADD_MEMBER
+	op.ProjectId = projectId
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, nil)
+}
+
+func deleteAPIMember(w http.ResponseWriter, r *http.Request, c *connection) {
+	projectId, ok := apiPathId(w, r, "projectId")
+	if !ok {
+		return
+	}
+	memberId, ok := apiPathId(w, r, "memberId")
+	if !ok {
+		return
+	}
+	op := &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_DELETE_MEMBER,
+		ProjectId: projectId,
+		MemberId:  memberId,
+	}
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, nil)
+}
+
+func getAPICredentials(w http.ResponseWriter, r *http.Request, c *connection) {
+	projectId, ok := apiPathId(w, r, "projectId")
+	if !ok {
+		return
+	}
+	op := &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_LIST_CREDENTIALS,
+		ProjectId: projectId,
+	}
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, func(res *pb.Response) interface{} {
+		return res.ProjectOpResponse.Credentials
+	})
+}
+
+func getAPICredential(w http.ResponseWriter, r *http.Request, c *connection) {
+	projectId, ok := apiPathId(w, r, "projectId")
+	if !ok {
+		return
+	}
+	op := &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_GET_CREDENTIAL,
+		ProjectId: projectId,
+		Key:       mux.Vars(r)["key"],
+	}
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, func(res *pb.Response) interface{} {
+		return res.ProjectOpResponse.Credential
+	})
+}
+
+func putAPICredential(w http.ResponseWriter, r *http.Request, c *connection) {
+	projectId, ok := apiPathId(w, r, "projectId")
+	if !ok {
+		return
+	}
+	op := &pb.ProjectOperation{}
+	if !decodeAPIRequest(w, r, op, maxAPICredentialRequestSize) {
+		return
+	}
+	op.Command = pb.ProjectOperation_ADD_CREDENTIAL
+	op.ProjectId = projectId
+	op.Key = mux.Vars(r)["key"]
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, func(res *pb.Response) interface{} {
+		return res.ProjectOpResponse.Credential
+	})
+}
+
+func deleteAPICredential(w http.ResponseWriter, r *http.Request, c *connection) {
+	projectId, ok := apiPathId(w, r, "projectId")
+	if !ok {
+		return
+	}
+	op := &pb.ProjectOperation{
+		Command:   pb.ProjectOperation_DELETE_CREDENTIAL,
+		ProjectId: projectId,
+		Key:       mux.Vars(r)["key"],
+	}
+	c.performAPIOperation(w, &pb.Operation{ProjectOp: op}, nil)
+}
+
+func getAPIMessages(w http.ResponseWriter, r *http.Request, c *connection) {
+	q := r.URL.Query()
+	op := &pb.MessageOperation{
+		Command:    pb.MessageOperation_LIST,
+		Sender:     q.Get("sender"),
+		Subject:    q.Get("subject"),
+		UnreadOnly: q.Get("unreadOnly") == "true",
+	}
+	page, _ := strconv.Atoi(q.Get("page"))
+	perPage, _ := strconv.Atoi(q.Get("perPage"))
+	op.Page, op.PerPage = int32(page), int32(perPage)
+	op.Since, _ = strconv.ParseInt(q.Get("since"), 10, 64)
+	op.Until, _ = strconv.ParseInt(q.Get("until"), 10, 64)
+	c.performAPIOperation(w, &pb.Operation{MessageOp: op}, func(res *pb.Response) interface{} {
+		return &struct {
+			Messages []*pb.Message `json:"messages"`
+			Total    int32         `json:"total"`
+			Page     int32         `json:"page"`
+			PerPage  int32         `json:"perPage"`
+		}{res.MessageOpResponse.Messages, res.MessageOpResponse.Total, res.MessageOpResponse.Page, res.MessageOpResponse.PerPage}
+	})
+}
+
+func postAPIMessage(w http.ResponseWriter, r *http.Request, c *connection) {
+	op := &pb.MessageOperation{}
+	if !decodeAPIRequest(w, r, op, maxAPIMessageRequestSize) {
+		return
+	}
+	op.Command = pb.MessageOperation_SEND
+	c.performAPIOperation(w, &pb.Operation{MessageOp: op}, nil)
+}
+
+func getAPIMessage(w http.ResponseWriter, r *http.Request, c *connection) {
+	messageId, ok := apiPathId(w, r, "messageId")
+	if !ok {
+		return
+	}
+	op := &pb.MessageOperation{
+		Command:   pb.MessageOperation_GET,
+		MessageId: messageId,
+	}
+	c.performAPIOperation(w, &pb.Operation{MessageOp: op}, func(res *pb.Response) interface{} {
+		return res.MessageOpResponse.Message
+	})
+}
+
+// messageCommandHandler handles endpoints that run a message command without a result, e.g. marking a message read
+func messageCommandHandler(command pb.MessageOperation_Command) apiHandler {
+	return func(w http.ResponseWriter, r *http.Request, c *connection) {
+		messageId, ok := apiPathId(w, r, "messageId")
+		if !ok {
+			return
+		}
+		op := &pb.MessageOperation{
+			Command:   command,
+			MessageId: messageId,
+		}
+		c.performAPIOperation(w, &pb.Operation{MessageOp: op}, nil)
+	}
+}
+
+// addAPIRoutes adds the routes of the API to r
+func addAPIRoutes(r *mux.Router) {
+	r.HandleFunc(APIURL+"/openapi.json", GetAPIDocument).Methods("GET")
+	r.HandleFunc(APIURL+"/tokens", PostAPIToken).Methods("POST")
+	r.HandleFunc(APIURL+"/tokens/current", withAPIToken(deleteAPIToken)).Methods("DELETE")
+	r.HandleFunc(APIURL+"/keys", withAPIToken(getAPIKeys)).Methods("GET")
+	r.HandleFunc(APIURL+"/projects", withAPIToken(getAPIProjects)).Methods("GET")
+	r.HandleFunc(APIURL+"/projects", withAPIToken(postAPIProject)).Methods("POST")
+	r.HandleFunc(APIURL+"/projects/{projectId}/members", withAPIToken(postAPIMember)).Methods("POST")
+	r.HandleFunc(APIURL+"/projects/{projectId}/members/{memberId}", withAPIToken(deleteAPIMember)).Methods("DELETE")
+	r.HandleFunc(APIURL+"/projects/{projectId}/credentials", withAPIToken(getAPICredentials)).Methods("GET")
+	r.HandleFunc(APIURL+"/projects/{projectId}/credentials/{key}", withAPIToken(getAPICredential)).Methods("GET")
+	r.HandleFunc(APIURL+"/projects/{projectId}/credentials/{key}", withAPIToken(putAPICredential)).Methods("PUT")
+	r.HandleFunc(APIURL+"/projects/{projectId}/credentials/{key}", withAPIToken(deleteAPICredential)).Methods("DELETE")
+	r.HandleFunc(APIURL+"/messages", withAPIToken(getAPIMessages)).Methods("GET")
+	r.HandleFunc(APIURL+"/messages", withAPIToken(postAPIMessage)).Methods("POST")
+	r.HandleFunc(APIURL+"/messages/{messageId}", withAPIToken(getAPIMessage)).Methods("GET")
+	r.HandleFunc(APIURL+"/messages/{messageId}", withAPIToken(messageCommandHandler(pb.MessageOperation_DELETE))).Methods("DELETE")
+	r.HandleFunc(APIURL+"/messages/{messageId}/read", withAPIToken(messageCommandHandler(pb.MessageOperation_MARK_READ))).Methods("POST")
+	r.HandleFunc(APIURL+"/messages/{messageId}/unread", withAPIToken(messageCommandHandler(pb.MessageOperation_MARK_UNREAD))).Methods("POST")
+}
@@ -0,0 +1,197 @@
+package web
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/rajivnavada/cryptzd/crypto"
+	"time"
+)
+
+// Broadcaster carries hub broadcasts between cryptzd instances so a push made on one instance reaches the
+// connections of every instance. Broadcasts are opaque bytes to it.
+type Broadcaster interface {
+	// Publish hands a broadcast made on this instance to the other instances
+	Publish(payload []byte) error
+
+	// Receive calls deliver with every broadcast made on another instance until Close is called
+	Receive(deliver func(payload []byte))
+
+	Close() error
+}
+
+// envelope is a hub broadcast as it travels between instances. Exactly one of the fields is set.
+type envelope struct {
+	Messages     map[string]push    `json:",omitempty"`
+	User         *push              `json:",omitempty"`
+	Notification *userNotification  `json:",omitempty"`
+	ProjectEvent *projectEvent      `json:",omitempty"`
+	Disconnect   *disconnectRequest `json:",omitempty"`
+}
+
+// bus is the broadcaster of this instance. A single instance gets by without one.
+var bus Broadcaster = localBroadcaster{}
+
+// UseBroadcaster makes the hub exchange broadcasts with other instances through b. Call it before serving requests.
+func UseBroadcaster(b Broadcaster) {
+	bus = b
+	go b.Receive(func(payload []byte) {
+		e := envelope{}
+		if err := json.Unmarshal(payload, &e); err != nil {
+			logError(err, "Error decoding broadcast from another instance")
+			return
+		}
+		H.deliver(e)
+	})
+}
+
+// broadcast delivers e to the connections of this instance and publishes it to the other instances
+func broadcast(e envelope) {
+	H.deliver(e)
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		logError(err, "Error encoding broadcast")
+		return
+	}
+	if err = bus.Publish(payload); err != nil {
+		logError(err, "Error publishing broadcast")
+	}
+}
+
+// deliver hands the broadcast to the hub loop. Broadcasts made after the hub stopped are dropped.
+func (h *Hub) deliver(e envelope) {
+	switch {
+	case e.Messages != nil:
+		select {
+		case h.broadcastMessage <- e.Messages:
+		case <-h.done:
+		}
+	case e.User != nil:
+		select {
+		case h.broadcastUser <- *e.User:
+		case <-h.done:
+		}
+	case e.Notification != nil:
+		select {
+		case h.notifyUser <- *e.Notification:
+		case <-h.done:
+		}
+	case e.ProjectEvent != nil:
+		select {
+		case h.broadcastProjectEvent <- *e.ProjectEvent:
+		case <-h.done:
+		}
+	case e.Disconnect != nil:
+		select {
+		case h.disconnect <- *e.Disconnect:
+		case <-h.done:
+		}
+	}
+}
+
+// localBroadcaster is used when there is a single instance. It has no one to talk to.
+type localBroadcaster struct{}
+
+func (localBroadcaster) Publish(payload []byte) error {
+	return nil
+}
+
+func (localBroadcaster) Receive(deliver func(payload []byte)) {
+}
+
+func (localBroadcaster) Close() error {
+	return nil
+}
+
+// DatabaseBroadcaster exchanges broadcasts through a table in the database the instances share.
+// Every instance polls the table, so pushes from other instances arrive up to one interval late.
+type DatabaseBroadcaster struct {
+	// origin tells the broadcasts of this instance apart from the ones it has to deliver
+	origin   string
+	interval time.Duration
+	quit     chan struct{}
+}
+
+func NewDatabaseBroadcaster(interval time.Duration) (*DatabaseBroadcaster, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &DatabaseBroadcaster{
+		origin:   hex.EncodeToString(b),
+		interval: interval,
+		quit:     make(chan struct{}),
+	}, nil
+}
+
+func (db *DatabaseBroadcaster) Publish(payload []byte) error {
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return err
+	}
+	defer dbMap.Close()
+
+	_, err = crypto.NewHubBroadcast(db.origin, payload, dbMap)
+	return err
+}
+
+// Receive starts with the broadcasts made after it was called. Anything older was meant for connections that are gone.
+func (db *DatabaseBroadcaster) Receive(deliver func(payload []byte)) {
+	lastId := db.lastId()
+
+	ticker := time.NewTicker(db.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-db.quit:
+			return
+		case <-ticker.C:
+		}
+
+		if lastId < 0 {
+			lastId = db.lastId()
+			continue
+		}
+
+		dbMap, err := crypto.NewDataMapper()
+		if err != nil {
+			logError(err, "Could not create instance of DataMapper for the broadcaster")
+			continue
+		}
+		broadcasts, err := crypto.FindHubBroadcastsAfter(lastId, db.origin, dbMap)
+		dbMap.Close()
+		if err != nil {
+			logError(err, fmt.Sprintf("Error reading broadcasts after %d", lastId))
+			continue
+		}
+		for _, b := range broadcasts {
+			deliver(b.Payload())
+			lastId = b.Id()
+		}
+	}
+}
+
+// lastId returns the id of the latest broadcast, or -1 if it can't be read
+func (db *DatabaseBroadcaster) lastId() int {
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		logError(err, "Could not create instance of DataMapper for the broadcaster")
+		return -1
+	}
+	defer dbMap.Close()
+
+	id, err := crypto.LastHubBroadcastId(dbMap)
+	if err != nil {
+		logError(err, "Error finding the last broadcast")
+		return -1
+	}
+	return id
+}
+
+func (db *DatabaseBroadcaster) Close() error {
+	close(db.quit)
+	return nil
+}
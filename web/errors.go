@@ -0,0 +1,108 @@
+package web
+
+import (
+	"database/sql"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+)
+
+// Error codes of the errors operations return as is
+var errorCodes = map[error]pb.Response_ErrorCode{
+	ErrInvalidArgsForProjectOp:             pb.Response_INVALID_ARGUMENT,
+	ErrInvalidArgsForCredentialOp:          pb.Response_INVALID_ARGUMENT,
+	ErrInvalidArgsForAdminOp:               pb.Response_INVALID_ARGUMENT,
+	ErrInvalidArgsForVaultOp:               pb.Response_INVALID_ARGUMENT,
+	ErrInvalidArgsForMessageOp:             pb.Response_INVALID_ARGUMENT,
+	ErrInvalidArgsForEventOp:               pb.Response_INVALID_ARGUMENT,
+	InvalidTTLError:                        pb.Response_INVALID_ARGUMENT,
+	crypto.InvalidArgumentsForMessageError: pb.Response_INVALID_ARGUMENT,
+	crypto.ServiceAccountReadOnlyError:     pb.Response_INVALID_ARGUMENT,
+	ErrNoAccess:                            pb.Response_NO_ACCESS,
+	NotAnAdminError:                        pb.Response_NO_ACCESS,
+	crypto.ServiceAccountNotManagedError:   pb.Response_NO_ACCESS,
+	crypto.ServiceAccountEmailInUseError:   pb.Response_ALREADY_EXISTS,
+	ErrChunkOutOfOrder:                     pb.Response_FAILED_PRECONDITION,
+	ErrDuplicateOpId:                       pb.Response_FAILED_PRECONDITION,
+	ErrDuplicateHello:                      pb.Response_FAILED_PRECONDITION,
+	CannotTargetSelfError:                  pb.Response_FAILED_PRECONDITION,
+	crypto.LastProjectAdminError:           pb.Response_FAILED_PRECONDITION,
+	crypto.NoActivePublicKeysError:         pb.Response_FAILED_PRECONDITION,
+	crypto.NoProjectRecipientsError:        pb.Response_FAILED_PRECONDITION,
+	crypto.RevokedKeyError:                 pb.Response_FAILED_PRECONDITION,
+	ErrUnknownOperation:                    pb.Response_NOT_FOUND,
+	crypto.AttachmentTooLargeError:         pb.Response_TOO_LARGE,
+	crypto.CredentialTooLargeError:         pb.Response_TOO_LARGE,
+	ErrUploadsTooLarge:                     pb.Response_TOO_LARGE,
+	ErrUnsupportedOperation:                pb.Response_UNSUPPORTED,
+	crypto.NotImplementedError:             pb.Response_UNSUPPORTED,
+	ErrTooManyOperations:                   pb.Response_TOO_MANY_OPERATIONS,
+	ErrOperationTimedOut:                   pb.Response_TIMED_OUT,
+	ErrOperationCancelled:                  pb.Response_CANCELLED,
+	ErrShuttingDown:                        pb.Response_SHUTTING_DOWN,
+	ErrHelloRequired:                       pb.Response_UPGRADE_REQUIRED,
+	ErrClientTooOld:                        pb.Response_UPGRADE_REQUIRED,
+	UserSuspendedError:                     pb.Response_SUSPENDED,
+	MissingAPITokenError:                   pb.Response_UNAUTHENTICATED,
+	ErrAuthenticationRequired:              pb.Response_UNAUTHENTICATED,
+	InactiveKeyError:                       pb.Response_UNAUTHENTICATED,
+	crypto.APITokenNotFoundError:           pb.Response_UNAUTHENTICATED,
+	InvalidAPIRequestError:                 pb.Response_INVALID_ARGUMENT,
+	InvalidAPIPathError:                    pb.Response_INVALID_ARGUMENT,
+}
+
+// Messages for unique indexes clients can run into, by index name
+var alreadyExistsMessages = map[string]string{
+	"uniq_p_name_environment":              "A project with the same name already exists in this environment.",
+	"uniq_pm_project_id_user_id":           "The user is already a member of this project.",
+	"uniq_pck_project_id_key":              "A credential with the same key already exists in this project.",
+	"uniq_pcv_credential_id_member_id":     "The credential already has a value for this member.",
+	"uniq_pcv_credential_id_public_key_id": "The credential already has a value for this key.",
+	"uniq_uc_public_key_id_key":            "A vault entry with the same key already exists.",
+	"users.email":                          "A user with the same email address already exists.",
+	"public_keys.fingerprint":              "A key with the same fingerprint already exists.",
+}
+
+// errorResponse is a failed response to the operation opId
+func errorResponse(opId int32, err error) *pb.Response {
+	result := &pb.Response{OpId: opId}
+	setError(result, err)
+	return result
+}
+
+// Sent instead of the message of errors nobody expected, which can give away how the server works
+const internalErrorMessage = "Something went wrong on the server. Try again in a moment."
+
+// setError fails the response with err. The error is classified so clients don't have to parse the message,
+// and raw database errors are replaced with a message meant for people. Unclassified errors are logged and
+// replaced with a generic message.
+func setError(result *pb.Response, err error) {
+	result.Status = pb.Response_ERROR
+	result.Error = err.Error()
+	result.ErrorCode = pb.Response_INTERNAL
+	result.ErrorDetails = nil
+
+	if code, ok := errorCodes[err]; ok {
+		result.ErrorCode = code
+		return
+	}
+
+	if crypto.IsNotFound(err) {
+		result.ErrorCode = pb.Response_NOT_FOUND
+		if err == sql.ErrNoRows {
+			result.Error = "Not found."
+		}
+	} else if constraint, ok := crypto.UniqueConstraint(err); ok {
+		result.ErrorCode = pb.Response_ALREADY_EXISTS
+		result.Error = "A record with the same values already exists."
+		if msg, ok := alreadyExistsMessages[constraint]; ok {
+			result.Error = msg
+		}
+		result.ErrorDetails = []*pb.ErrorDetail{{Key: "constraint", Value: constraint}}
+	} else if crypto.IsForeignKeyViolation(err) {
+		result.ErrorCode = pb.Response_INVALID_ARGUMENT
+		result.Error = "The operation refers to a record that does not exist."
+	} else {
+		logError(err, "Internal error in operation")
+		result.Error = internalErrorMessage
+	}
+}
@@ -0,0 +1,102 @@
+package web
+
+import (
+	"bytes"
+	"github.com/golang/protobuf/proto"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+)
+
+// Browsers get HTML pushed to them. CLI clients get the same pushes as pb.Event values wrapped in a pb.Response
+// so they only ever read one kind of frame off the websocket.
+// Pushes are rendered by whoever publishes them so the hub loop only ever queues bytes.
+
+// push is a payload rendered for both kinds of connection
+type push struct {
+	HTML []byte
+	CLI  []byte
+}
+
+// payload picks the rendering meant for the connection
+func (p push) payload(c *connection) []byte {
+	if c.isCLI {
+		return p.CLI
+	}
+	return p.HTML
+}
+
+// marshalEvent wraps the event in the response CLI clients read off the websocket
+func marshalEvent(event *pb.Event) ([]byte, error) {
+	return proto.Marshal(&pb.Response{
+		Status: pb.Response_SUCCESS,
+		Event:  event,
+	})
+}
+
+// eventId returns the id of the event in a CLI payload, 0 for pushes that aren't acknowledged
+func eventId(payload []byte) int32 {
+	res := &pb.Response{}
+	if err := proto.Unmarshal(payload, res); err != nil || res.Event == nil {
+		return 0
+	}
+	return res.Event.EventId
+}
+
+func newMessageEvent(d delivery) *pb.Event {
+	m := newPbMessage(d.Message)
+	// View once messages are only readable through a GET, which also deletes them
+	if d.Message.ViewOnce() {
+		m.Cipher = ""
+	}
+	return &pb.Event{
+		Type:    pb.Event_MESSAGE,
+		EventId: int32(d.EventId),
+		Message: m,
+	}
+}
+
+func newNotificationEvent(d delivery) *pb.Event {
+	return &pb.Event{
+		Type:         pb.Event_NOTIFICATION,
+		EventId:      int32(d.EventId),
+		Notification: d.Text,
+	}
+}
+
+func newUserActivatedEvent(u crypto.User) *pb.Event {
+	return &pb.Event{
+		Type: pb.Event_USER_ACTIVATED,
+		User: newPbUser(u, nil),
+	}
+}
+
+func newProjectEvent(event *pb.ProjectEvent) *pb.Event {
+	return &pb.Event{
+		Type:         pb.Event_PROJECT,
+		ProjectEvent: event,
+	}
+}
+
+func renderMessage(d delivery) (push, error) {
+	buf := &bytes.Buffer{}
+	if err := messageTemplate.Execute(buf, d); err != nil {
+		return push{}, err
+	}
+	cli, err := marshalEvent(newMessageEvent(d))
+	if err != nil {
+		return push{}, err
+	}
+	return push{HTML: buf.Bytes(), CLI: cli}, nil
+}
+
+func renderUserActivated(user messagesTemplateExtensions) (push, error) {
+	buf := &bytes.Buffer{}
+	if err := userTemplate.Execute(buf, user); err != nil {
+		return push{}, err
+	}
+	cli, err := marshalEvent(newUserActivatedEvent(user.CurrentUser))
+	if err != nil {
+		return push{}, err
+	}
+	return push{HTML: buf.Bytes(), CLI: cli}, nil
+}
@@ -0,0 +1,190 @@
+package web
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"net/http"
+	"strings"
+)
+
+// The gRPC service performs the same operations as the websocket. Every call authenticates with an API token and
+// is performed by a connection acting for the key the token was issued to, the way the HTTP API does it.
+// Calls don't share a connection, so uploads in chunks can't span calls. Values are sent whole instead.
+
+// gRPC status codes of API tokens that can't be used, by the HTTP status the API answers with
+var grpcStatusCodes = map[int]codes.Code{
+	http.StatusUnauthorized:        codes.Unauthenticated,
+	http.StatusForbidden:           codes.PermissionDenied,
+	http.StatusInternalServerError: codes.Internal,
+}
+
+// gRPC status codes of the errors an Events call can fail with before it starts streaming
+var grpcErrorCodes = map[pb.Response_ErrorCode]codes.Code{
+	pb.Response_INVALID_ARGUMENT: codes.InvalidArgument,
+	pb.Response_NOT_FOUND:        codes.NotFound,
+	pb.Response_NO_ACCESS:        codes.PermissionDenied,
+	pb.Response_SHUTTING_DOWN:    codes.Unavailable,
+}
+
+type cryptzServer struct{}
+
+// NewGRPCServer returns a server offering the Cryptz service over TLS with creds
+func NewGRPCServer(creds credentials.TransportCredentials) *grpc.Server {
+	s := grpc.NewServer(
+		grpc.Creds(creds),
+		// Messages carry their attachments
+		grpc.MaxRecvMsgSize(int(maxMessageSize+crypto.MaxAttachmentSize)),
+	)
+	pb.RegisterCryptzServer(s, &cryptzServer{})
+	return s
+}
+
+// grpcConnection authenticates the call with the API token in its metadata and returns a connection acting for its key
+func grpcConnection(ctx context.Context) (*connection, error) {
+	token := ""
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, h := range md["authorization"] {
+		if strings.HasPrefix(h, apiTokenPrefix) {
+			token = strings.TrimSpace(strings.TrimPrefix(h, apiTokenPrefix))
+		}
+	}
+	if token == "" {
+		return nil, status.Error(codes.Unauthenticated, MissingAPITokenError.Error())
+	}
+
+	c, httpStatus, err := connectionForToken(token)
+	if err != nil {
+		code, ok := grpcStatusCodes[httpStatus]
+		if !ok {
+			code = codes.Internal
+		}
+		return nil, status.Error(code, errorResponse(0, err).Error)
+	}
+	return c, nil
+}
+
+// grpcCapabilities lists what the service performs. Uploads in chunks are left out, along with the operations the
+// service has no call for. gRPC deadlines and cancellation take the place of the cancel and timeout features.
+func grpcCapabilities() []string {
+	var ret []string
+	for _, capability := range serverCapabilities() {
+		if strings.HasPrefix(capability, "admin.") || strings.Contains(capability, ".UPLOAD_") ||
+			capability == "cancel" || capability == "timeout" {
+			continue
+		}
+		ret = append(ret, capability)
+	}
+	return ret
+}
+
+// perform authenticates the call and performs the operation. Operations that fail come back as a response with an
+// errorCode, the same one they'd get over the websocket.
+func (s *cryptzServer) perform(ctx context.Context, op *pb.Operation, upload bool) (*pb.Response, error) {
+	c, err := grpcConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if upload {
+		return errorResponse(0, ErrUnsupportedOperation), nil
+	}
+	if err := H.startOperation(); err != nil {
+		return errorResponse(0, err), nil
+	}
+	result := c.perform(op)
+	H.inFlight.Done()
+
+	// Operations start out failed with ErrUnsupportedOperation and only clear the message once they succeed
+	if result.Status == pb.Response_SUCCESS {
+		result.ErrorCode = pb.Response_NONE
+		result.ErrorDetails = nil
+	}
+	return result, nil
+}
+
+func (s *cryptzServer) Handshake(ctx context.Context, hello *pb.Hello) (*pb.HelloResponse, error) {
+	c, err := grpcConnection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	version, err := c.negotiateProtocol(hello)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &pb.HelloResponse{
+		ProtocolVersion:    version,
+		MinProtocolVersion: minProtocolVersion,
+		Capabilities:       grpcCapabilities(),
+	}, nil
+}
+
+func (s *cryptzServer) Project(ctx context.Context, op *pb.ProjectOperation) (*pb.Response, error) {
+	upload := op.Command == pb.ProjectOperation_UPLOAD_CREDENTIAL_CHUNK
+	return s.perform(ctx, &pb.Operation{ProjectOp: op}, upload)
+}
+
+func (s *cryptzServer) Vault(ctx context.Context, op *pb.VaultOperation) (*pb.Response, error) {
+	upload := op.Command == pb.VaultOperation_UPLOAD_CHUNK
+	return s.perform(ctx, &pb.Operation{VaultOp: op}, upload)
+}
+
+func (s *cryptzServer) Message(ctx context.Context, op *pb.MessageOperation) (*pb.Response, error) {
+	upload := op.Command == pb.MessageOperation_UPLOAD_ATTACHMENT_CHUNK
+	return s.perform(ctx, &pb.Operation{MessageOp: op}, upload)
+}
+
+func (s *cryptzServer) Ack(ctx context.Context, op *pb.EventOperation) (*pb.Response, error) {
+	return s.perform(ctx, &pb.Operation{EventOp: op}, false)
+}
+
+// Events streams the pushes of the key until the call ends. Unacknowledged events after req.LastEventId are replayed
+// first, then the stream carries what the websocket would.
+func (s *cryptzServer) Events(req *pb.EventsRequest, stream pb.Cryptz_EventsServer) error {
+	c, err := grpcConnection(stream.Context())
+	if err != nil {
+		return err
+	}
+
+	c.startPushes()
+	defer H.drop(c)
+
+	if len(req.ProjectIds) > 0 {
+		if _, err := c.subscribe(&pb.ProjectOperation{ProjectIds: req.ProjectIds}); err != nil {
+			result := errorResponse(0, err)
+			code, ok := grpcErrorCodes[result.ErrorCode]
+			if !ok {
+				code = codes.Internal
+			}
+			return status.Error(code, result.Error)
+		}
+	}
+
+	for {
+		select {
+		case payload, ok := <-c.send:
+			if !ok {
+				return status.Error(codes.Unavailable, "Connection closed by the server.")
+			}
+			res := &pb.Response{}
+			if err := proto.Unmarshal(payload, res); err != nil {
+				logError(err, "Error unmarshaling push in Events")
+				continue
+			}
+			if res.Event == nil || (res.Event.EventId != 0 && res.Event.EventId <= req.LastEventId) {
+				continue
+			}
+			if err := stream.Send(res.Event); err != nil {
+				return err
+			}
+
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
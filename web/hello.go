@@ -0,0 +1,116 @@
+package web
+
+import (
+	"fmt"
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"sort"
+	"strings"
+)
+
+const (
+	// Version of the websocket protocol spoken by the server. Bump it whenever an older client would misread the server.
+	protocolVersion = 2
+
+	// Oldest protocol version the server still accepts. Version 2 introduced HELLO and protobuf event pushes.
+	minProtocolVersion = 2
+)
+
+// Features a client can rely on that aren't a single command
+var protocolFeatures = []string{
+	"cancel",  // Operation.cancelOpId
+	"timeout", // Operation.timeout
+	"events",  // pb.Event pushes, acknowledged with event.ACK
+}
+
+// serverCapabilities lists the commands the server performs, e.g. "project.LIST", followed by its protocol features
+func serverCapabilities() []string {
+	var ret []string
+	add := func(prefix string, names map[int32]string) {
+		for _, name := range names {
+			ret = append(ret, prefix+"."+name)
+		}
+	}
+	add("project", pb.ProjectOperation_Command_name)
+	add("admin", pb.AdminOperation_Command_name)
+	add("vault", pb.VaultOperation_Command_name)
+	add("message", pb.MessageOperation_Command_name)
+	add("event", pb.EventOperation_Command_name)
+	sort.Strings(ret)
+	return append(ret, protocolFeatures...)
+}
+
+// hello answers the HELLO a CLI client sends before any other operation. Clients older than minProtocolVersion are
+// turned away with an upgrade message. Newer clients are told which version the server speaks so they can fall back to it.
+// It reports whether the handshake succeeded.
+func (c *connection) hello(opQuery *pb.Operation) bool {
+	hello := opQuery.GetHello()
+	result := errorResponse(opQuery.OpId, ErrUnsupportedOperation)
+	result.HelloResponse = &pb.HelloResponse{
+		ProtocolVersion:    protocolVersion,
+		MinProtocolVersion: minProtocolVersion,
+		Capabilities:       serverCapabilities(),
+	}
+	defer c.reply(result)
+
+	switch {
+	case !c.isCLI:
+		setError(result, ErrUnsupportedOperation)
+	case c.protocolVersion != 0:
+		setError(result, ErrDuplicateHello)
+	default:
+		version, err := c.negotiateProtocol(hello)
+		if err != nil {
+			setError(result, err)
+			break
+		}
+		c.protocolVersion = version
+		result.HelloResponse.ProtocolVersion = c.protocolVersion
+		result.Status = pb.Response_SUCCESS
+		result.Error = ""
+		result.Info = fmt.Sprintf("Speaking protocol version %d", c.protocolVersion)
+	}
+	return result.Status == pb.Response_SUCCESS
+}
+
+// negotiateProtocol picks the protocol version to speak with a client that said HELLO, or returns ErrClientTooOld
+func (c *connection) negotiateProtocol(hello *pb.Hello) (int32, error) {
+	if hello.ProtocolVersion < minProtocolVersion {
+		logIt(fmt.Sprintf("Turned away key with fingerprint %s using %s (protocol version %d)", c.fingerprint, hello.ClientVersion, hello.ProtocolVersion))
+		return 0, ErrClientTooOld
+	}
+	version := hello.ProtocolVersion
+	if version > protocolVersion {
+		version = protocolVersion
+	}
+	logIt(fmt.Sprintf("Key with fingerprint %s connected using %s (protocol version %d, capabilities: %s)",
+		c.fingerprint, hello.ClientVersion, hello.ProtocolVersion, strings.Join(hello.Capabilities, ", ")))
+	return version, nil
+}
+
+// startPushes registers the connection with the hub once the client said HELLO and catches it up on everything it
+// missed while it was offline. Clients that never say HELLO can't read the pushes, so they never get any.
+// Live pushes are held back until the events the key missed are queued ahead of them, so events arrive in order.
+func (c *connection) startPushes() {
+	c.replaying = true
+	select {
+	case H.register <- c:
+	case <-H.done:
+		return
+	}
+
+	b := backlog{c: c}
+	dbMap, err := crypto.NewDataMapper()
+	if err == nil {
+		b, err = c.backlog(dbMap)
+		dbMap.Close()
+	}
+	if err != nil {
+		logError(err, fmt.Sprintf("Error replaying events for key with fingerprint %s", c.fingerprint))
+	}
+
+	select {
+	case H.replay <- b:
+	case <-H.done:
+	}
+}
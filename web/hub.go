@@ -0,0 +1,385 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"github.com/rajivnavada/cryptzd/crypto"
+	"sync"
+)
+
+// connectionSet holds the connections open for a key or a user
+type connectionSet map[*connection]bool
+
+// hub maintains the set of active connections and broadcasts messages to the
+// connections.
+type Hub struct {
+	// Registered connections by key fingerprint.
+	connections map[fingerprint]connectionSet
+
+	// Registered connections by user.
+	users map[userId]connectionSet
+
+	// Channel to broadcast messages to connected users, by key fingerprint
+	broadcastMessage chan map[string]push
+
+	// Channel to broadcast new user activations
+	broadcastUser chan push
+
+	// Register requests from the connections.
+	register chan *connection
+
+	// Unregister requests from connections.
+	unregister chan *connection
+
+	// Requests to drop the connections of a user or key, e.g. after a suspension
+	disconnect chan disconnectRequest
+
+	// Channel to send a notification to every connection of a user
+	notifyUser chan userNotification
+
+	// Connections subscribed to events of a project, by project id
+	subscribers map[int]connectionSet
+
+	// Requests to change the projects a connection is subscribed to
+	subscribe chan subscription
+
+	// Channel to push project events to subscribed connections
+	broadcastProjectEvent chan projectEvent
+
+	// Events connections missed while offline, queued ahead of the pushes held back while they were read
+	replay chan backlog
+
+	// Operations in flight on every connection, so a shutdown can wait for them
+	inFlight sync.WaitGroup
+	// Set once the hub is shutting down, new operations are turned away after that
+	draining bool
+	// Protects draining
+	drainLock sync.Mutex
+
+	// Tells Run to close every connection and return
+	quit chan struct{}
+	// Closed once Run has returned
+	done chan struct{}
+}
+
+// disconnectRequest matches connections by user or by key fingerprint
+type disconnectRequest struct {
+	UserId      userId
+	Fingerprint fingerprint
+}
+
+// userNotification is a short informational text shown to a user, e.g. when a view once message they sent was read
+type userNotification struct {
+	UserId userId
+
+	HTML []byte
+
+	// CLI holds the event for each key of the user since every key has its own event id.
+	// Keys without an event get the one stored under 0.
+	CLI map[publicKeyId][]byte
+}
+
+// backlog holds the events a connection missed while it was offline
+type backlog struct {
+	c        *connection
+	payloads [][]byte
+	// Id of the last event in payloads
+	lastEventId int32
+	// Unset when some of the events didn't fit or couldn't be read
+	complete bool
+}
+
+// delivery is a message or notification pushed to a key. CLI clients acknowledge the event once they have it
+// and get every unacknowledged event replayed when they connect.
+type delivery struct {
+	EventId int
+	Message crypto.EncryptedMessage
+	Text    string
+}
+
+// H is the hub every connection of the server registers with
+var H = newHub()
+
+func newHub() *Hub {
+	return &Hub{
+		broadcastMessage:      make(chan map[string]push),
+		broadcastUser:         make(chan push),
+		register:              make(chan *connection),
+		unregister:            make(chan *connection),
+		disconnect:            make(chan disconnectRequest),
+		notifyUser:            make(chan userNotification),
+		subscribe:             make(chan subscription),
+		broadcastProjectEvent: make(chan projectEvent),
+		replay:                make(chan backlog),
+		connections:           make(map[fingerprint]connectionSet),
+		users:                 make(map[userId]connectionSet),
+		subscribers:           make(map[int]connectionSet),
+		quit:                  make(chan struct{}),
+		done:                  make(chan struct{}),
+	}
+}
+
+// Run makes the hub ready to receive / broadcast connections
+func (h *Hub) Run() {
+	defer close(h.done)
+
+	// NOTE: the reason the delete's don't need to be guarded by a mutex here is because each
+	//       'case' is handled synchronously.
+	for {
+		select {
+		case <-h.quit:
+			for _, conns := range h.connections {
+				for c := range conns {
+					if c.ws != nil {
+						c.goAway()
+					}
+					h.remove(c)
+				}
+			}
+			return
+
+		case c := <-h.register:
+			h.add(c)
+
+		case b := <-h.replay:
+			h.release(b)
+
+		case c := <-h.unregister:
+			h.remove(c)
+
+		case d := <-h.disconnect:
+			var matched []*connection
+			if d.UserId != 0 {
+				for c := range h.users[d.UserId] {
+					matched = append(matched, c)
+				}
+			}
+			if d.Fingerprint != "" {
+				for c := range h.connections[d.Fingerprint] {
+					matched = append(matched, c)
+				}
+			}
+			for _, c := range matched {
+				h.remove(c)
+			}
+
+		case messages := <-h.broadcastMessage:
+			// m is a map of fingerprint to the rendered message
+			for k, m := range messages {
+				// Send to every connection open with the key
+				for c := range h.connections[fingerprint(k)] {
+					h.send(c, m.payload(c))
+				}
+			}
+
+		case n := <-h.notifyUser:
+			for c := range h.users[n.UserId] {
+				if !c.isCLI {
+					h.send(c, n.HTML)
+				} else if payload, ok := n.CLI[c.keyId]; ok {
+					h.send(c, payload)
+				} else {
+					h.send(c, n.CLI[0])
+				}
+			}
+
+		case s := <-h.subscribe:
+			h.unsubscribe(s.c)
+			// The connection may have gone away while the subscription was checked
+			if !h.connections[s.c.fingerprint][s.c] {
+				break
+			}
+			for _, id := range s.projectIds {
+				if h.subscribers[id] == nil {
+					h.subscribers[id] = make(connectionSet)
+				}
+				h.subscribers[id][s.c] = true
+			}
+			s.c.projects = s.projectIds
+
+		case e := <-h.broadcastProjectEvent:
+			for c := range h.subscribers[e.ProjectId] {
+				// Users who lost access to the project stop receiving its events
+				if !e.Readers[c.userId] {
+					delete(h.subscribers[e.ProjectId], c)
+					continue
+				}
+				h.send(c, e.Payload)
+			}
+			if len(h.subscribers[e.ProjectId]) == 0 {
+				delete(h.subscribers, e.ProjectId)
+			}
+
+		case user := <-h.broadcastUser:
+			for _, conns := range h.connections {
+				for c := range conns {
+					h.send(c, user.payload(c))
+				}
+			}
+		}
+	}
+}
+
+// add registers a connection under its key and its user. A key can have any number of connections open,
+// e.g. the web UI and a couple of terminals.
+func (h *Hub) add(c *connection) {
+	if h.connections[c.fingerprint][c] {
+		return
+	}
+	hubMetrics.Add("connections", 1)
+	if h.connections[c.fingerprint] == nil {
+		h.connections[c.fingerprint] = make(connectionSet)
+	}
+	h.connections[c.fingerprint][c] = true
+	if h.users[c.userId] == nil {
+		h.users[c.userId] = make(connectionSet)
+	}
+	h.users[c.userId][c] = true
+}
+
+// release queues the events a connection missed, followed by the pushes held back while they were read.
+// Held events that were replayed are skipped, and so are all held events when some of the missed ones didn't fit.
+func (h *Hub) release(b backlog) {
+	c := b.c
+	if !h.connections[c.fingerprint][c] {
+		return
+	}
+	held := c.held
+	c.replaying, c.held = false, nil
+	for _, payload := range b.payloads {
+		h.send(c, payload)
+	}
+	c.behind = !b.complete
+	for _, payload := range held {
+		if id := eventId(payload); id != 0 && id <= b.lastEventId {
+			continue
+		}
+		h.send(c, payload)
+	}
+}
+
+// remove unregisters a single connection and closes it. Other connections of the key are left alone.
+func (h *Hub) remove(c *connection) {
+	if h.connections[c.fingerprint][c] {
+		hubMetrics.Add("connections", -1)
+	}
+	if conns, ok := h.connections[c.fingerprint]; ok {
+		delete(conns, c)
+		if len(conns) == 0 {
+			delete(h.connections, c.fingerprint)
+		}
+	}
+	if conns, ok := h.users[c.userId]; ok {
+		delete(conns, c)
+		if len(conns) == 0 {
+			delete(h.users, c.userId)
+		}
+	}
+	h.unsubscribe(c)
+	c.held = nil
+	c.closeChan()
+}
+
+// unsubscribe drops every project subscription of a connection
+func (h *Hub) unsubscribe(c *connection) {
+	for _, id := range c.projects {
+		if conns, ok := h.subscribers[id]; ok {
+			delete(conns, c)
+			if len(conns) == 0 {
+				delete(h.subscribers, id)
+			}
+		}
+	}
+	c.projects = nil
+}
+
+// send queues a payload on a connection. When the queue is full the overflow policy of the connection decides
+// whether the payload or the connection is dropped. Either way the hub moves on without waiting for the client.
+// Connections closed since they were registered are skipped, they are on their way to being unregistered.
+func (h *Hub) send(c *connection, payload []byte) {
+	// The client picks up the events it is behind on by connecting again
+	if c.behind && eventId(payload) != 0 {
+		return
+	}
+
+	queued, closed := false, false
+	if c.replaying {
+		if queued = len(c.held) < sendQueueSize; queued {
+			c.held = append(c.held, payload)
+		}
+	} else {
+		c.lock.Lock()
+		closed = c.closed
+		if !closed {
+			select {
+			case c.send <- payload:
+				queued = true
+			default:
+			}
+		}
+		c.lock.Unlock()
+	}
+
+	switch {
+	case closed:
+	case queued:
+		hubMetrics.Add("pushes", 1)
+	default:
+		switch c.overflow {
+		case overflowDrop:
+			hubMetrics.Add("dropped", 1)
+		default:
+			hubMetrics.Add("disconnected", 1)
+			logIt(fmt.Sprintf("Disconnecting slow connection for key with fingerprint %s", c.fingerprint))
+			h.remove(c)
+		}
+	}
+}
+
+// drop unregisters a connection and closes it. Connections are only ever closed by the hub, so it never pushes
+// to one that is closed. Once the hub has stopped, it closed every connection it knew about on its way out.
+func (h *Hub) drop(c *connection) {
+	select {
+	case h.unregister <- c:
+	case <-h.done:
+		c.closeChan()
+	}
+}
+
+// Shutdown turns away new operations and waits for the ones in flight until ctx is done.
+// Every connection is then closed with a going away close frame and Run returns.
+// The channels of the hub are left open since handlers that are still running may send on them.
+func (h *Hub) Shutdown(ctx context.Context) error {
+	h.drainLock.Lock()
+	h.draining = true
+	h.drainLock.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		h.inFlight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	close(h.quit)
+	<-h.done
+	return err
+}
+
+// startOperation counts an operation as in flight unless the hub is shutting down
+func (h *Hub) startOperation() error {
+	h.drainLock.Lock()
+	defer h.drainLock.Unlock()
+
+	if h.draining {
+		return ErrShuttingDown
+	}
+	h.inFlight.Add(1)
+	return nil
+}
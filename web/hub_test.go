@@ -0,0 +1,228 @@
+package web
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	loadTestConnections = 5000
+	loadTestPushes      = sendQueueSize + 50
+)
+
+// dialTestWebsocket opens a single websocket and returns it with the server behind it.
+// The load test shares it between every connection since the hub only ever closes it, the payloads never leave the send queues.
+func dialTestWebsocket(t *testing.T) (*websocket.Conn, *httptest.Server) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}))
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+	return ws, srv
+}
+
+func metric(name string) int64 {
+	if v, ok := hubMetrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+	return 0
+}
+
+func isClosed(c *connection) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.closed
+}
+
+// TestHubLoad broadcasts to thousands of connections while some of them never read their queue.
+// The slow ones must not hold up the rest: browsers lose the overflow, CLI clients get disconnected.
+func TestHubLoad(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping hub load test in short mode")
+	}
+
+	ws, srv := dialTestWebsocket(t)
+	defer srv.Close()
+	h := newHub()
+	go h.Run()
+
+	conns := make([]*connection, loadTestConnections)
+	for i := range conns {
+		conns[i] = newConnection(ws, userId(i), publicKeyId(i), fingerprint(fmt.Sprintf("F%d", i)), i%2 == 0, false)
+		h.register <- conns[i]
+	}
+
+	// Every fifth connection never reads its queue
+	slow := func(i int) bool { return i%5 == 0 }
+	var wg sync.WaitGroup
+	for i, c := range conns {
+		if slow(i) {
+			continue
+		}
+		wg.Add(1)
+		go func(c *connection) {
+			defer wg.Done()
+			for j := 0; j < loadTestPushes; j++ {
+				if _, ok := <-c.send; !ok {
+					return
+				}
+			}
+		}(c)
+	}
+
+	dropped, disconnected := metric("dropped"), metric("disconnected")
+	start := time.Now()
+	for j := 0; j < loadTestPushes; j++ {
+		h.broadcastUser <- push{HTML: []byte("html"), CLI: []byte("cli")}
+	}
+	// The hub is done with the last broadcast once it takes the next request
+	h.disconnect <- disconnectRequest{}
+	wg.Wait()
+	t.Logf("Pushed %d payloads to %d connections in %s", loadTestPushes, loadTestConnections, time.Since(start))
+	t.Logf("Dropped %d pushes and disconnected %d connections", metric("dropped")-dropped, metric("disconnected")-disconnected)
+
+	for i, c := range conns {
+		if !slow(i) {
+			continue
+		}
+		if c.isCLI && !isClosed(c) {
+			t.Fatalf("Slow CLI connection %d was not disconnected", i)
+		}
+		if !c.isCLI && (isClosed(c) || len(c.send) != sendQueueSize) {
+			t.Fatalf("Slow browser connection %d should be open with a full queue, has %d queued", i, len(c.send))
+		}
+	}
+	if n := metric("dropped") - dropped; n < int64(loadTestConnections/10*(loadTestPushes-sendQueueSize)) {
+		t.Fatalf("Expected the overflow of slow browser connections to be dropped, only %d pushes were", n)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestHubOutsideClose broadcasts while connections are dropped from outside the hub loop, the way a reply that times
+// out drops one, and closed outright. Run it with -race.
+func TestHubOutsideClose(t *testing.T) {
+	ws, srv := dialTestWebsocket(t)
+	defer srv.Close()
+	h := newHub()
+	go h.Run()
+
+	conns := make([]*connection, loadTestConnections/10)
+	for i := range conns {
+		conns[i] = newConnection(ws, userId(i), publicKeyId(i), fingerprint(fmt.Sprintf("F%d", i)), true, false)
+		h.register <- conns[i]
+	}
+
+	var wg sync.WaitGroup
+	for i, c := range conns {
+		wg.Add(1)
+		go func(i int, c *connection) {
+			defer wg.Done()
+			for j := 0; ; j++ {
+				if j == 10 {
+					switch i % 3 {
+					case 0:
+						h.drop(c)
+					case 1:
+						c.closeChan()
+					}
+				}
+				if _, ok := <-c.send; !ok {
+					return
+				}
+			}
+		}(i, c)
+	}
+
+	for j := 0; j < loadTestPushes; j++ {
+		h.broadcastUser <- push{HTML: []byte("html"), CLI: []byte("cli")}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	for i, c := range conns {
+		if !isClosed(c) {
+			t.Fatalf("Connection %d is still open after the hub shut down", i)
+		}
+	}
+}
+
+// TestHubReplay pushes events while a connection reads the events it missed. They must reach it after the replayed
+// ones, without the ones it replayed twice.
+func TestHubReplay(t *testing.T) {
+	ws, srv := dialTestWebsocket(t)
+	defer srv.Close()
+	h := newHub()
+	go h.Run()
+	defer h.Shutdown(context.Background())
+
+	event := func(id int) []byte {
+		payload, err := marshalEvent(newNotificationEvent(delivery{EventId: id, Text: "text"}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return payload
+	}
+	live := func(c *connection, id int) {
+		h.broadcastMessage <- map[string]push{string(c.fingerprint): {CLI: event(id)}}
+	}
+	received := func(c *connection) []int32 {
+		// The hub is done with the last request once it takes the next one
+		h.disconnect <- disconnectRequest{}
+		var ids []int32
+		for len(c.send) > 0 {
+			ids = append(ids, eventId(<-c.send))
+		}
+		return ids
+	}
+
+	c := newConnection(ws, 1, 1, "REPLAY", true, false)
+	c.replaying = true
+	h.register <- c
+	live(c, 3)
+	live(c, 4)
+	h.replay <- backlog{c: c, payloads: [][]byte{event(1), event(2), event(3)}, lastEventId: 3, complete: true}
+	live(c, 5)
+	if ids := fmt.Sprint(received(c)); ids != "[1 2 3 4 5]" {
+		t.Fatalf("Expected events 1 to 5 in order, got %s", ids)
+	}
+
+	// Events after a backlog that didn't fit are left for the next connection to replay
+	c = newConnection(ws, 2, 2, "BEHIND", true, false)
+	c.replaying = true
+	h.register <- c
+	live(c, 3)
+	h.replay <- backlog{c: c, payloads: [][]byte{event(1)}, lastEventId: 1}
+	live(c, 4)
+	if ids := fmt.Sprint(received(c)); ids != "[1]" {
+		t.Fatalf("Expected only the replayed event, got %s", ids)
+	}
+}
@@ -0,0 +1,491 @@
+package web
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/gorilla/mux"
+	"github.com/rajivnavada/cryptzd/crypto"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MessagesURLBase = "/messages/"
+
+	inboxDateFormat = "2006-01-02"
+
+	SenderFilterName     = "sender"
+	SubjectFilterName    = "subject"
+	SinceFilterName      = "since"
+	UntilFilterName      = "until"
+	UnreadOnlyFilterName = "unread"
+	PageFilterName       = "page"
+)
+
+// inboxPage holds the filters and paging state of the messages list
+type inboxPage struct {
+	Sender     string
+	Subject    string
+	Since      string
+	Until      string
+	UnreadOnly bool
+	Page       int
+	TotalPages int
+	Total      int
+
+	PrevPageURL string
+	NextPageURL string
+}
+
+// newMessageFilter builds a crypto.MessageFilter from the query string of the request
+func newMessageFilter(q url.Values) (crypto.MessageFilter, *inboxPage) {
+	ip := &inboxPage{
+		Sender:     strings.TrimSpace(q.Get(SenderFilterName)),
+		Subject:    strings.TrimSpace(q.Get(SubjectFilterName)),
+		Since:      strings.TrimSpace(q.Get(SinceFilterName)),
+		Until:      strings.TrimSpace(q.Get(UntilFilterName)),
+		UnreadOnly: q.Get(UnreadOnlyFilterName) != "",
+	}
+	ip.Page, _ = strconv.Atoi(q.Get(PageFilterName))
+	if ip.Page < 1 {
+		ip.Page = 1
+	}
+
+	filter := crypto.MessageFilter{
+		SenderEmail: ip.Sender,
+		Subject:     ip.Subject,
+		UnreadOnly:  ip.UnreadOnly,
+		Page:        ip.Page,
+		PerPage:     crypto.DEFAULT_MESSAGES_PER_PAGE,
+	}
+	if t, err := time.Parse(inboxDateFormat, ip.Since); err == nil {
+		filter.Since = t
+	}
+	// The until date is inclusive so we filter up to the start of the next day
+	if t, err := time.Parse(inboxDateFormat, ip.Until); err == nil {
+		filter.Until = t.AddDate(0, 0, 1)
+	}
+	return filter, ip
+}
+
+// setTotal records the number of matching messages and builds the links to the neighbouring pages
+func (ip *inboxPage) setTotal(total int, q url.Values) {
+	ip.Total = total
+	ip.TotalPages = (total + crypto.DEFAULT_MESSAGES_PER_PAGE - 1) / crypto.DEFAULT_MESSAGES_PER_PAGE
+
+	pageURL := func(page int) string {
+		v := url.Values{}
+		for k, vals := range q {
+			v[k] = vals
+		}
+		v.Set(PageFilterName, strconv.Itoa(page))
+		return IndexURL + "?" + v.Encode()
+	}
+	if ip.Page > 1 {
+		ip.PrevPageURL = pageURL(ip.Page - 1)
+	}
+	if ip.Page < ip.TotalPages {
+		ip.NextPageURL = pageURL(ip.Page + 1)
+	}
+}
+
+func markMessageRead(m crypto.EncryptedMessage, dbMap crypto.DataMapper) error {
+	m.MarkRead()
+	return m.Save(dbMap)
+}
+
+func markMessageUnread(m crypto.EncryptedMessage, dbMap crypto.DataMapper) error {
+	m.MarkUnread()
+	return m.Save(dbMap)
+}
+
+func deleteMessage(m crypto.EncryptedMessage, dbMap crypto.DataMapper) error {
+	return m.Delete(dbMap)
+}
+
+// consumeMessage removes a view once message that was just fetched and lets the sender know it was read
+func consumeMessage(m crypto.EncryptedMessage, readerEmail string, dbMap crypto.DataMapper) error {
+	if err := m.Consume(dbMap); err != nil {
+		return err
+	}
+	if sender := m.Sender(); sender != nil {
+		notifyUser(sender.Id(), fmt.Sprintf("Your view once message \"%s\" was read by %s and has been deleted.", m.Subject(), readerEmail), dbMap)
+	}
+	return nil
+}
+
+// notifyUser queues a notification for every key of the user and pushes it to the ones that are connected
+func notifyUser(uid int, text string, dbMap crypto.DataMapper) {
+	buf := &bytes.Buffer{}
+	if err := notificationTemplate.Execute(buf, delivery{Text: text}); err != nil {
+		logError(err, "Error constructing notification")
+		return
+	}
+	n := userNotification{
+		UserId: userId(uid),
+		HTML:   buf.Bytes(),
+		CLI:    make(map[publicKeyId][]byte),
+	}
+
+	events, err := crypto.NewNotificationEvents(uid, text, dbMap)
+	if err != nil {
+		// The notification still goes out to connected keys, it just can't be replayed
+		logError(err, "Error queueing notification events")
+	}
+	// Keys without an event, e.g. because queueing it failed, get the notification without an event id
+	keyIds := map[publicKeyId]int{0: 0}
+	for keyId, e := range events {
+		keyIds[publicKeyId(keyId)] = e.Id()
+	}
+	for keyId, eventId := range keyIds {
+		payload, err := marshalEvent(newNotificationEvent(delivery{EventId: eventId, Text: text}))
+		if err != nil {
+			logError(err, "Error constructing notification event")
+			return
+		}
+		n.CLI[keyId] = payload
+	}
+	broadcast(envelope{Notification: &n})
+}
+
+// queueMessages queues an event for each message and pushes the messages to the keys that are connected
+func queueMessages(messages map[string]crypto.EncryptedMessage, dbMap crypto.DataMapper) {
+	pushes := make(map[string]push)
+	for fpr, m := range messages {
+		d := delivery{Message: m}
+		if e, err := crypto.NewMessageEvent(m.PublicKeyId(), m.Id(), dbMap); err != nil {
+			logError(err, fmt.Sprintf("Error queueing event for message %d", m.Id()))
+		} else {
+			d.EventId = e.Id()
+		}
+		p, err := renderMessage(d)
+		if err != nil {
+			logError(err, fmt.Sprintf("Error constructing message %d", m.Id()))
+			continue
+		}
+		pushes[fpr] = p
+	}
+	broadcast(envelope{Messages: pushes})
+}
+
+// RunMessagePurger deletes expired messages, stale events and old hub broadcasts every interval. It never returns.
+func RunMessagePurger(interval time.Duration) {
+	for range time.Tick(interval) {
+		dbMap, err := crypto.NewDataMapper()
+		if err != nil {
+			logError(err, "Could not create instance of DataMapper for the message purger")
+			continue
+		}
+		n, err := crypto.PurgeExpiredMessages(dbMap)
+		if err != nil {
+			logError(err, "Error purging expired messages")
+		} else if n > 0 {
+			logIt(fmt.Sprintf("Purged %d expired messages", n))
+		}
+		n, err = crypto.PurgeStaleEvents(dbMap)
+		if err != nil {
+			logError(err, "Error purging stale events")
+		} else if n > 0 {
+			logIt(fmt.Sprintf("Purged %d stale events", n))
+		}
+		n, err = crypto.PurgeHubBroadcasts(dbMap)
+		if err != nil {
+			logError(err, "Error purging hub broadcasts")
+		} else if n > 0 {
+			logIt(fmt.Sprintf("Purged %d hub broadcasts", n))
+		}
+		n, err = crypto.PurgeExpiredAPITokens(dbMap)
+		if err != nil {
+			logError(err, "Error purging expired API tokens")
+		} else if n > 0 {
+			logIt(fmt.Sprintf("Purged %d expired API tokens", n))
+		}
+		dbMap.Close()
+	}
+}
+
+// findSessionMessage finds the message in the URL if it was encrypted to the key of the current session.
+// It writes an error response and returns nil otherwise.
+func findSessionMessage(w http.ResponseWriter, r *http.Request, sess *SessionObject, dbMap crypto.DataMapper) crypto.EncryptedMessage {
+	key, err := crypto.FindPublicKeyWithFingerprint(sess.KeyFingerprint, dbMap)
+	if !assertErrorIsNil(w, err, "Error finding key with fingerprint"+sess.KeyFingerprint) {
+		return nil
+	}
+
+	messageId, err := strconv.Atoi(mux.Vars(r)["messageId"])
+	if err != nil {
+		http.Error(w, "Invalid messageId", http.StatusBadRequest)
+		return nil
+	}
+
+	m, err := crypto.FindMessageForPublicKey(messageId, key.Id(), dbMap)
+	if err == crypto.MessageNotFoundError {
+		http.NotFound(w, r)
+		return nil
+	} else if !assertErrorIsNil(w, err, "Error finding message") {
+		return nil
+	}
+	return m
+}
+
+// handleMessageAction runs the action on a message that belongs to the key of the current session
+func handleMessageAction(w http.ResponseWriter, r *http.Request, action func(crypto.EncryptedMessage, crypto.DataMapper) error) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	m := findSessionMessage(w, r, sess, dbMap)
+	if m == nil {
+		return
+	}
+
+	if !assertErrorIsNil(w, action(m, dbMap), "Error handling "+r.URL.String()) {
+		return
+	}
+
+	// Send the user back to the page of the inbox they were looking at
+	redirectTo := IndexURL
+	if ref, err := url.Parse(r.Referer()); err == nil && ref.Path == IndexURL {
+		redirectTo = ref.RequestURI()
+	}
+	http.Redirect(w, r, redirectTo, http.StatusSeeOther)
+}
+
+// PostMessageOpen shows a view once message and removes it from the server
+func PostMessageOpen(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	m := findSessionMessage(w, r, sess, dbMap)
+	if m == nil {
+		return
+	}
+
+	// Attachments go away with a view once message so their ciphers are shown on this page
+	var attachments []crypto.MessageAttachment
+	for _, a := range m.Attachments() {
+		ma, err := crypto.FindAttachmentForPublicKey(a.Id(), m.PublicKeyId(), dbMap)
+		if !assertErrorIsNil(w, err, "Error finding attachment of message") {
+			return
+		}
+		attachments = append(attachments, ma)
+	}
+
+	if m.ViewOnce() {
+		if !assertErrorIsNil(w, consumeMessage(m, sess.UserEmail, dbMap), "Error consuming view once message") {
+			return
+		}
+	}
+
+	templateDefs := newTemplateArgs()
+	templateDefs.Extensions = &struct {
+		Message     crypto.EncryptedMessage
+		Attachments []crypto.MessageAttachment
+	}{
+		Message:     m,
+		Attachments: attachments,
+	}
+	if err := openMessageTemplate.Execute(w, templateDefs); err != nil {
+		panic(err)
+	}
+}
+
+// GetMessageAttachment downloads the armored cipher of an attachment.
+// The file is base64 encoded before it is encrypted, so decrypt it and decode the result to get the original back.
+func GetMessageAttachment(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	m := findSessionMessage(w, r, sess, dbMap)
+	if m == nil {
+		return
+	}
+	if m.ViewOnce() {
+		http.Error(w, "Attachments of a view once message are shown when the message is opened", http.StatusBadRequest)
+		return
+	}
+
+	attachmentId, err := strconv.Atoi(mux.Vars(r)["attachmentId"])
+	if err != nil {
+		http.Error(w, "Invalid attachmentId", http.StatusBadRequest)
+		return
+	}
+
+	a, err := crypto.FindAttachmentForPublicKey(attachmentId, m.PublicKeyId(), dbMap)
+	if err == crypto.AttachmentNotFoundError || (err == nil && a.MessageId() != m.Id()) {
+		http.NotFound(w, r)
+		return
+	} else if !assertErrorIsNil(w, err, "Error finding attachment") {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pgp-encrypted")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename() + ".asc"}))
+	w.Write(a.Cipher())
+}
+
+// threadEntry is a message in a thread. Sent messages are encrypted to the other user and can't be read by the viewer.
+type threadEntry struct {
+	Message crypto.EncryptedMessage
+	Sent    bool
+}
+
+// GetMessageThread shows the conversation the message belongs to with a form to reply to the latest message from the other user
+func GetMessageThread(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	m := findSessionMessage(w, r, sess, dbMap)
+	if m == nil {
+		return
+	}
+
+	key, err := crypto.FindKeyWithId(m.PublicKeyId(), dbMap)
+	if !assertErrorIsNil(w, err, "Error finding key of message") {
+		return
+	}
+
+	messages, err := key.Thread(m.ThreadId(), dbMap)
+	if !assertErrorIsNil(w, err, "Error extracting thread for message") {
+		return
+	}
+
+	var entries []threadEntry
+	var replyTo crypto.EncryptedMessage
+	for _, tm := range messages {
+		sent := tm.PublicKeyId() != key.Id()
+		entries = append(entries, threadEntry{Message: tm, Sent: sent})
+		if !sent {
+			replyTo = tm
+		}
+	}
+
+	templateDefs := newTemplateArgs()
+	templateDefs.Extensions = &struct {
+		Entries                     []threadEntry
+		ReplyTo                     crypto.EncryptedMessage
+		SubjectFormFieldName        string
+		MessageFormFieldName        string
+		TTLFormFieldName            string
+		ViewOnceFormFieldName       string
+		EncryptSubjectFormFieldName string
+		AttachmentFormFieldName     string
+		MaxAttachmentSize           int64
+		EncryptSubjects             bool
+	}{
+		Entries:                     entries,
+		ReplyTo:                     replyTo,
+		SubjectFormFieldName:        SubjectFormFieldName,
+		MessageFormFieldName:        MessageFormFieldName,
+		TTLFormFieldName:            TTLFormFieldName,
+		ViewOnceFormFieldName:       ViewOnceFormFieldName,
+		EncryptSubjectFormFieldName: EncryptSubjectFormFieldName,
+		AttachmentFormFieldName:     AttachmentFormFieldName,
+		MaxAttachmentSize:           crypto.MaxAttachmentSize,
+		EncryptSubjects:             crypto.EncryptSubjects,
+	}
+
+	if err := threadTemplate.Execute(w, templateDefs); err != nil {
+		panic(err)
+	}
+}
+
+// PostMessageReply sends a reply to the sender of the message and goes back to the thread
+func PostMessageReply(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	m := findSessionMessage(w, r, sess, dbMap)
+	if m == nil {
+		return
+	}
+
+	sender, err := sess.User(dbMap)
+	if !assertErrorIsNil(w, err, "Could not find sender with Email "+sess.UserEmail) {
+		return
+	}
+
+	message := strings.TrimSpace(r.FormValue(MessageFormFieldName))
+	if message == "" {
+		http.Error(w, MissingMessageError.Error(), http.StatusBadRequest)
+		return
+	}
+	subject := strings.TrimSpace(r.FormValue(SubjectFormFieldName))
+
+	opts, err := messageOptionsFromRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if _, err := replyToMessage(m, sender, message, subject, opts, dbMap); !assertErrorIsNil(w, err, "Error replying to message") {
+		return
+	}
+
+	http.Redirect(w, r, fmt.Sprintf("%s%d/thread", MessagesURLBase, m.Id()), http.StatusSeeOther)
+}
+
+// replyToMessage sends the reply and delivers it to the connected keys of the recipient
+func replyToMessage(m crypto.EncryptedMessage, sender crypto.User, message, subject string, opts crypto.MessageOptions, dbMap crypto.DataMapper) (map[string]crypto.EncryptedMessage, error) {
+	encryptedMessages, err := m.Reply(sender, message, subject, opts, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	queueMessages(encryptedMessages, dbMap)
+	return encryptedMessages, nil
+}
+
+func PostMessageRead(w http.ResponseWriter, r *http.Request) {
+	handleMessageAction(w, r, markMessageRead)
+}
+
+func PostMessageUnread(w http.ResponseWriter, r *http.Request) {
+	handleMessageAction(w, r, markMessageUnread)
+}
+
+func PostMessageDelete(w http.ResponseWriter, r *http.Request) {
+	handleMessageAction(w, r, deleteMessage)
+}
@@ -0,0 +1,30 @@
+package web
+
+import (
+	"expvar"
+	"github.com/rajivnavada/cryptzd/crypto"
+	"net/http"
+)
+
+// hubMetrics counts what the hub does with pushes:
+//
+//	connections     open websocket connections
+//	pushes          payloads queued on a connection
+//	dropped         payloads dropped because a browser connection had a full queue
+//	disconnected    CLI connections dropped because their queue was full
+//	repliesTimedOut connections dropped because an operation response couldn't be queued in time
+var hubMetrics = expvar.NewMap("hub")
+
+// GetAdminMetrics serves every published expvar, the hub metrics included, as JSON
+func GetAdminMetrics(w http.ResponseWriter, r *http.Request) {
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	if admin := mustBeAdmin(w, r, dbMap); admin == nil {
+		return
+	}
+	expvar.Handler().ServeHTTP(w, r)
+}
@@ -1,6 +1,7 @@
 package web
 
 import (
+	"github.com/rajivnavada/cryptzd/crypto"
 	"log"
 	"net/http"
 	"net/url"
@@ -82,6 +83,21 @@ func mustBeAuthenticated(w http.ResponseWriter, r *http.Request) *SessionObject
 		http.Redirect(w, r, LoginURL, http.StatusSeeOther)
 		return nil
 	}
+
+	// Suspended users lose access right away, even if they hold a valid session
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return nil
+	}
+	defer dbMap.Close()
+
+	if u, err := session.User(dbMap); err == nil && u.IsSuspended() {
+		if err := session.Destroy(w, r); err != nil {
+			logError(err, "Error destroying session of suspended user")
+		}
+		http.Error(w, UserSuspendedError.Error(), http.StatusForbidden)
+		return nil
+	}
 	return session
 }
 
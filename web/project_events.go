@@ -0,0 +1,97 @@
+package web
+
+import (
+	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"time"
+)
+
+// projectEvent is a change to a project pushed to the connections subscribed to it
+type projectEvent struct {
+	ProjectId int
+	Payload   []byte
+
+	// Readers are the users that can read the project once the change is made. Only their connections get the event.
+	Readers map[userId]bool
+}
+
+// subscription replaces the projects a connection receives events for
+type subscription struct {
+	c          *connection
+	projectIds []int
+}
+
+// publishProjectEvent pushes the event to every connection subscribed to the project whose user is still a member
+func publishProjectEvent(p crypto.Project, event *pb.ProjectEvent, dbMap crypto.DataMapper) {
+	members, err := p.Members(dbMap)
+	if err != nil {
+		logError(err, "Error loading members for project event")
+		return
+	}
+	readers := make(map[userId]bool)
+	for _, m := range members {
+		readers[userId(m.UserId())] = true
+	}
+	event.ProjectId = int32(p.Id())
+	event.CreatedAt = time.Now().UTC().Unix()
+	payload, err := marshalEvent(newProjectEvent(event))
+	if err != nil {
+		logError(err, "Error marshaling project event")
+		return
+	}
+	broadcast(envelope{ProjectEvent: &projectEvent{ProjectId: p.Id(), Payload: payload, Readers: readers}})
+}
+
+// publishMemberEvent publishes a member event for m. The email is looked up since clients show members by email.
+func publishMemberEvent(p crypto.Project, eventType pb.ProjectEvent_Type, m crypto.ProjectMember, dbMap crypto.DataMapper) {
+	event := &pb.ProjectEvent{
+		Type:        eventType,
+		MemberId:    int32(m.Id()),
+		UserId:      int32(m.UserId()),
+		AccessLevel: m.AccessLevel(),
+	}
+	if u, err := m.User(dbMap); err == nil {
+		event.MemberEmail = u.Email()
+	}
+	publishProjectEvent(p, event, dbMap)
+}
+
+// subscribe checks the user can read every project before replacing the subscriptions of the connection
+func (c *connection) subscribe(op *pb.ProjectOperation) ([]*pb.Project, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForProjectOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	var ret []*pb.Project
+	var projectIds []int
+	for _, id := range op.ProjectIds {
+		p, err := crypto.FindProjectWithId(int(id), dbMap)
+		if err != nil {
+			return nil, err
+		}
+		if !p.HasMemberWithUserId(int(c.userId), dbMap) {
+			return nil, ErrNoAccess
+		}
+		projectIds = append(projectIds, p.Id())
+		ret = append(ret, &pb.Project{
+			Id:          int32(p.Id()),
+			Name:        p.Name(),
+			Environment: p.Environment(),
+		})
+	}
+
+	select {
+	case H.subscribe <- subscription{c: c, projectIds: projectIds}:
+	case <-H.done:
+		// The hub stopped, the connection is about to be closed
+		return nil, ErrShuttingDown
+	}
+	return ret, nil
+}
@@ -7,15 +7,25 @@ import (
 )
 
 type messagesTemplateExtensions struct {
-	Session              *SessionObject
-	Messages             []crypto.EncryptedMessage
-	Users                []crypto.User
-	CurrentUser          crypto.User
-	FormActionName       string
-	UserIdFormFieldName  string
-	SubjectFormFieldName string
-	MessageFormFieldName string
-	WebSocketURL         string
+	Session                     *SessionObject
+	Messages                    []crypto.EncryptedMessage
+	Inbox                       *inboxPage
+	Users                       []crypto.User
+	Projects                    []crypto.Project
+	CurrentUser                 crypto.User
+	FormActionName              string
+	UserIdFormFieldName         string
+	ProjectIdFormFieldName      string
+	SubjectFormFieldName        string
+	MessageFormFieldName        string
+	TTLFormFieldName            string
+	ViewOnceFormFieldName       string
+	EncryptSubjectFormFieldName string
+	AttachmentFormFieldName     string
+	MaxAttachmentSize           int64
+	EncryptSubjects             bool
+	WebSocketURL                string
+	AdminURL                    string
 }
 
 func (mte messagesTemplateExtensions) SetCurrentUser(user crypto.User) *messagesTemplateExtensions {
@@ -188,6 +198,13 @@ a:focus {
 .main-content .link-content .media-left .thumbnail { width: 64px; border-radius: 0; margin-bottom: 0; }
 .main-content .link-content .media-heading { padding-top: 3px; }
 .main-content .link-content .media-body .email { color: #888; margin-bottom: 5px; }
+.main-content .link-content .media-body .recipients { color: #888; font-size: 0.9em; }
+.main-content .link-content .message.unread .media-heading { font-weight: bold; }
+.main-content .link-content .media-body .expires { color: #a94442; font-size: 0.85em; }
+.main-content .link-content .message-actions form { display: inline-block; margin: 0; }
+.main-content .link-content .message-actions .btn-link { padding: 0 8px 0 0; font-size: 0.85em; }
+.main-content .link-content .message-filters { margin-bottom: 1em; }
+.main-content .link-content .message-pager .pager li { color: #888; }
 .main-content .link-content .form { padding: 20px; border: 1px solid #ccc; margin-top: 1em; }
 
 .link-content > div { padding: 1em 0; }
@@ -205,6 +222,19 @@ a:focus {
 		<div class="link">
 			<a href="#users" title="Users"><i class="glyphicon glyphicon-envelope"></i> Users</a>
 		</div>
+		<div class="link">
+			<a href="#group" title="Group message"><i class="glyphicon glyphicon-bullhorn"></i> Group</a>
+		</div>
+	</div>
+	<div class="page-links">
+		<div class="link">
+			<a href="/vault" title="Vault"><i class="glyphicon glyphicon-lock"></i> Vault</a>
+		</div>
+		{{ if .AdminURL }}
+		<div class="link">
+			<a href="{{ .AdminURL }}" title="Admin"><i class="glyphicon glyphicon-cog"></i> Admin</a>
+		</div>
+		{{ end }}
 	</div>
 	<div class="footer ctxt">
 		&copy; 2016
@@ -214,10 +244,25 @@ a:focus {
 	<div class="row">
 		<div class="col-xs-12 col-md-6">
 			<div class="link-content active" id="messages">
+				<form class="form-inline message-filters" method="GET" action="/">
+					<input class="form-control input-sm" type="text" name="sender" value="{{ .Inbox.Sender }}" placeholder="Sender email">
+					<input class="form-control input-sm" type="text" name="subject" value="{{ .Inbox.Subject }}" placeholder="Subject">
+					<input class="form-control input-sm" type="date" name="since" value="{{ .Inbox.Since }}" title="Since">
+					<input class="form-control input-sm" type="date" name="until" value="{{ .Inbox.Until }}" title="Until">
+					<label class="checkbox-inline"><input type="checkbox" name="unread" value="1" {{ if .Inbox.UnreadOnly }}checked{{ end }}> Unread only</label>
+					<button class="btn btn-default btn-sm" type="submit">Filter</button>
+				</form>
 				{{ if .Messages }}
 					{{ range $index, $message := .Messages }}
 						{{ template "Message" $message }}
 					{{ end }}
+					<nav class="message-pager">
+						<ul class="pager">
+							{{ if .Inbox.PrevPageURL }}<li class="previous"><a href="{{ .Inbox.PrevPageURL }}">Newer</a></li>{{ end }}
+							<li>Page {{ .Inbox.Page }} of {{ .Inbox.TotalPages }}</li>
+							{{ if .Inbox.NextPageURL }}<li class="next"><a href="{{ .Inbox.NextPageURL }}">Older</a></li>{{ end }}
+						</ul>
+					</nav>
 				{{ else }}
 					<h3 class="no-messages-header">No messages for you!</h3>
 				{{ end }}
@@ -229,6 +274,44 @@ a:focus {
 					{{ end }}
 				{{ end }}
 			</div>
+			<div class="link-content" id="group">
+				<div class="form group-message-form">
+					<form method="POST" action="{{ .FormActionName }}" enctype="multipart/form-data" accept-charset="UTF-8">
+						<div class="alert hidden"></div>
+						{{ if .Projects }}
+						<div class="form-group">
+							<label for="group-message-form-project">Send to every member of a project</label>
+							<select class="form-control" id="group-message-form-project" name="{{ .ProjectIdFormFieldName }}">
+								<option value="">None, send to the users selected below</option>
+								{{ range $index, $project := .Projects }}
+								<option value="{{ $project.Id }}">{{ $project.Name }} ({{ $project.Environment }})</option>
+								{{ end }}
+							</select>
+						</div>
+						{{ end }}
+						<div class="form-group">
+							<label for="group-message-form-users">Send to these users</label>
+							<select class="form-control" id="group-message-form-users" name="{{ .UserIdFormFieldName }}" multiple size="6">
+								{{ range $index, $user := .Users }}
+								<option value="{{ $user.Id }}">{{ $user.Name }} &lt;{{ $user.Email }}&gt;</option>
+								{{ end }}
+							</select>
+						</div>
+						<div class="form-group">
+							<label for="group-message-form-subject">Subject</label>
+							<input class="form-control" type="text" id="group-message-form-subject" name="{{ .SubjectFormFieldName }}" placeholder="Sending you all a cryptz message">
+						</div>
+						<div class="form-group">
+							<label for="group-message-form-message">Enter your message below</label>
+							<textarea class="form-control" rows="5" id="group-message-form-message" name="{{ .MessageFormFieldName }}" placeholder="Lorem Ipsum ..."></textarea>
+						</div>
+						{{ template "MessageOptions" . }}
+						<div class="form-group rtxt">
+							<button class="btn btn-default" type="submit">Send Message</button>
+						</div>
+					</form>
+				</div>
+			</div>
 		</div>
 	</div>
 </div>
@@ -242,6 +325,42 @@ $(function () {
 	var $linkContents = $('.main-content .link-content');
 	var $users = $('#users');
 	var $messages = $('#messages');
+	var $group = $('#group');
+
+	function wireMessageForms($forms) {
+
+		$forms.submit(function (e) {
+			var $this = $(this);
+			if ($this.hasClass('disabled')) {
+				return false;
+			}
+
+			$this.addClass('disabled').find('[type="submit"]').addClass('disabled');
+
+			var action = $.trim($this.attr('action'));
+
+			// FormData carries the attachments along with the rest of the form
+			$.ajax({
+				url: action,
+				type: 'POST',
+				data: new FormData(this),
+				processData: false,
+				contentType: false
+			}).done(function (data) {
+				var o = $.parseJSON(data);
+				if (o.errors && o.errors.length > 0) {
+					console.error(o.errors);
+					$this.find('.alert').html("There were some errors. Check console for details.").removeClass("hidden").addClass('alert-danger');
+				} else {
+					$this.find('.alert').html("Message sent successfully").addClass('alert-success').removeClass('hidden');
+				}
+			}).fail(function () {
+				$this.find('.alert').html("There were some errors. Check console for details.").removeClass("hidden").addClass('alert-danger error');
+			});
+
+			return false;
+		});
+	}
 
 	function wireUserMedia($elements) {
 
@@ -278,33 +397,7 @@ $(function () {
 			return false;
 		});
 
-		$messageForms.
-			find('form').
-				submit(function (e) {
-					var $this = $(this);
-					if ($this.hasClass('disabled')) {
-						return false;
-					}
-
-					$this.addClass('disabled').find('[type="submit"]').addClass('disabled');
-
-					var action = $.trim($this.attr('action'));
-					var data = $this.serialize();
-
-					$.post(action, $this.serialize(), function (data) {
-						var o = $.parseJSON(data);
-						if (o.errors && o.errors.length > 0) {
-							console.error(o.errors);
-							$this.find('.alert').html("There were some errors. Check console for details.").removeClass("hidden").addClass('alert-danger');
-						} else {
-							$this.find('.alert').html("Message sent successfully").addClass('alert-success').removeClass('hidden');
-						}
-					}).fail(function () {
-						$this.find('.alert').html("There were some errors. Check console for details.").removeClass("hidden").addClass('alert-danger error');
-					});
-
-					return false;
-		});
+		wireMessageForms($messageForms.find('form'));
 	}
 
 	$links.click(function (e) {
@@ -325,6 +418,7 @@ $(function () {
 	});
 
 	wireUserMedia($users.find('.media'));
+	wireMessageForms($group.find('form'));
 
 	var DEFAULT_RETRY_TIMEOUT = 1500;
 	var retryTimeout = DEFAULT_RETRY_TIMEOUT;
@@ -371,6 +465,13 @@ $(function () {
 					notification = new Notification(title, { body: subject });
 				}
 
+			} else if ($data.is('.notification')) {
+
+				$messages.prepend($data);
+				if (Notification.permission !== "denied") {
+					notification = new Notification('Cryptz', { body: $data.text() });
+				}
+
 			} else if ($data.is('.user') && curId && $("#" + curId).size() === 0) {
 
 				$users.prepend($data);
@@ -407,33 +508,88 @@ var messagesTemplate *template.Template
 
 var messageTemplateHtml = `
 {{ define "Message" }}
-<div class="media message">
+<div class="media message{{ if not .IsRead }} unread{{ end }}" id="message-{{ .Id }}">
 	<div class="media-left">
 		<p class="thumbnail">
 			<img class="media-object" src="{{ .Sender.ImageURL }}" alt="">
 		</p>
 	</div>
 	<div class="media-body">
+		{{ if .SubjectEncrypted }}
+		<h4 class="media-heading" title="The decrypted message starts with a Subject: line followed by a blank line and the body"><i class="glyphicon glyphicon-lock"></i> {{ .Subject }}</h4>
+		{{ else }}
 		<h4 class="media-heading">{{ .Subject }}</h4>
+		{{ end }}
 		<p class="email">{{ .Sender.Name }} &lt;{{ .Sender.Email }}&gt;</p>
+		{{ if .Recipients }}
+		<p class="recipients">To: {{ range $index, $user := .Recipients }}{{ if $index }}, {{ end }}{{ $user.Name }}{{ end }}</p>
+		{{ end }}
+		{{ if not .ExpiresAt.IsZero }}
+		<p class="expires">Deleted automatically on {{ .ExpiresAt.Format "Jan 2, 2006 at 15:04 MST" }}</p>
+		{{ end }}
+		<div class="message-actions">
+			{{ if .ViewOnce }}
+			<form method="POST" action="/messages/{{ .Id }}/open" target="_blank" onsubmit="var $m = $(this).closest('.message'); setTimeout(function () { $m.remove(); }, 0);"><button class="btn btn-link" type="submit">Open once</button></form>
+			{{ else if .IsRead }}
+			<form method="POST" action="/messages/{{ .Id }}/unread"><button class="btn btn-link" type="submit">Mark as unread</button></form>
+			{{ else }}
+			<form method="POST" action="/messages/{{ .Id }}/read"><button class="btn btn-link" type="submit">Mark as read</button></form>
+			{{ end }}
+			<form method="POST" action="/messages/{{ .Id }}/delete" onsubmit="return confirm('Delete this message?');"><button class="btn btn-link" type="submit">Delete</button></form>
+			<a class="btn btn-link" href="/messages/{{ .Id }}/thread">{{ if .ParentId }}View thread{{ else }}Reply{{ end }}</a>
+		</div>
 	</div>
+	{{ if .ViewOnce }}
+	<pre>This message can only be viewed once. It is deleted as soon as you open it.</pre>
+	{{ else }}
 	<pre>{{ printf "%s" .Cipher }}</pre>
+	{{ end }}
+	{{ if .Attachments }}
+	<ul class="attachments">
+		{{ range $index, $attachment := .Attachments }}
+		<li>
+			{{ if $.ViewOnce }}
+			{{ $attachment.Filename }}
+			{{ else }}
+			<a href="/messages/{{ $.Id }}/attachments/{{ $attachment.Id }}">{{ $attachment.Filename }}</a>
+			{{ end }}
+			<small>{{ $attachment.Size }} bytes</small>
+		</li>
+		{{ end }}
+	</ul>
+	{{ end }}
 </div>
 {{ end }}`
 
 var messageTemplate *template.Template
 
-var messageTemplateText = `
-{{ define "Message" }}
-Subject: {{ .Subject }}
-From: {{ .Sender.Name }} <{{ .Sender.Email }}>
+var notificationTemplateHtml = `<div class="notification alert alert-success">{{ .Text }}</div>`
 
-{{ printf "%s" .Cipher }}
-{{ end }}`
-
-var messageTextTemplate *textTemplate.Template
+var notificationTemplate *template.Template
 
 var userTemplateHtml = `
+{{ define "MessageOptions" }}
+<div class="form-group form-inline message-options">
+	<label>Delete after</label>
+	<select class="form-control input-sm" name="{{ .TTLFormFieldName }}">
+		<option value="">Never</option>
+		<option value="1h">1 hour</option>
+		<option value="24h">1 day</option>
+		<option value="168h">1 week</option>
+	</select>
+	<label class="checkbox-inline"><input type="checkbox" name="{{ .ViewOnceFormFieldName }}" value="1"> View once</label>
+	{{ if .EncryptSubjects }}
+	<span class="help-inline">Subjects are always encrypted on this server</span>
+	{{ else }}
+	<label class="checkbox-inline"><input type="checkbox" name="{{ .EncryptSubjectFormFieldName }}" value="1"> Encrypt subject</label>
+	{{ end }}
+</div>
+<div class="form-group message-attachments">
+	<label>Attachments</label>
+	<input type="file" name="{{ .AttachmentFormFieldName }}" multiple>
+	<p class="help-block">Up to {{ .MaxAttachmentSize }} bytes in total. Files are encrypted to the same keys as the message.</p>
+</div>
+{{ end }}
 {{ define "User" }}
 <div class="user" id="user-{{ .CurrentUser.Id }}">
 	<div class="media">
@@ -454,7 +610,7 @@ var userTemplateHtml = `
 		</div>
 	</div>
 	<div class="form message-form hidden">
-		<form method="POST" action="{{ .FormActionName }}" enctype="application/x-www-form-urlencoded" accept-charset="UTF-8">
+		<form method="POST" action="{{ .FormActionName }}" enctype="multipart/form-data" accept-charset="UTF-8">
 			<input type="hidden" name="{{ .UserIdFormFieldName  }}" value="{{ .CurrentUser.Id }}">
 			<div class="alert hidden"></div>
 			<div class="form-group">
@@ -465,6 +621,7 @@ var userTemplateHtml = `
 				<label for="send-message-form-message-{{ .CurrentUser.Id }}">Enter your message below</label>
 				<textarea class="form-control" rows="5" id="send-message-form-message-{{ .CurrentUser.Id }}" name="message" placeholder="Lorem Ipsum ..."></textarea>
 			</div>
+			{{ template "MessageOptions" . }}
 			<div class="form-group rtxt">
 				<button class="btn btn-default" type="submit">Send Message</button>
 			</div>
@@ -475,6 +632,283 @@ var userTemplateHtml = `
 
 var userTemplate *template.Template
 
+var openMessageTemplateHtml = `
+{{ define "HeadHTML" }}{{ end }}
+{{ define "HeadCSS" }}
+#main { width: 900px; }
+.alert.alert-warning { border-radius: 0; }
+{{ end }}
+{{ define "BodyMain" }}
+<div class="container-fluid tmargin">
+	<div class="row">
+		<div class="col-xs-12">
+			<h3>{{ .Message.Subject }} <small>{{ .Message.Sender.Name }} &lt;{{ .Message.Sender.Email }}&gt;</small></h3>
+			{{ if .Message.ViewOnce }}
+			<div class="alert alert-warning">This message has been deleted from the server. Copy it now, it cannot be opened again.</div>
+			{{ end }}
+			<pre>{{ printf "%s" .Message.Cipher }}</pre>
+			{{ range $index, $attachment := .Attachments }}
+			<h4>{{ $attachment.Filename }} <small>{{ $attachment.Size }} bytes. Decrypt it and base64 decode the result to get the file back.</small></h4>
+			<pre>{{ printf "%s" $attachment.Cipher }}</pre>
+			{{ end }}
+			<p><a href="/">Back to messages</a></p>
+		</div>
+	</div>
+</div>
+{{ end }}
+{{ define "BodyAfterMain" }}{{ end }}
+`
+
+var openMessageTemplate *template.Template
+
+var threadTemplateHtml = `
+{{ define "HeadHTML" }}{{ end }}
+{{ define "HeadCSS" }}
+#main { width: 900px; }
+.thread-entry { margin-bottom: 1.5em; }
+.thread-entry.sent { padding-left: 80px; }
+.thread-entry.sent .media-heading small { color: #888; }
+.message-actions form { display: inline-block; margin: 0; }
+.message-actions .btn-link { padding: 0 8px 0 0; font-size: 0.85em; }
+.media-body .email, .media-body .recipients, .media-body .expires { color: #888; }
+.media-left .thumbnail { width: 64px; border-radius: 0; margin-bottom: 0; }
+.reply-form { padding: 20px; border: 1px solid #ccc; margin-top: 1em; }
+{{ end }}
+{{ define "BodyMain" }}
+<div class="container-fluid tmargin">
+	<div class="row">
+		<div class="col-xs-12">
+			<h3>Thread <small><a href="/">Back to messages</a></small></h3>
+			{{ range $index, $entry := .Entries }}
+			<div class="thread-entry{{ if $entry.Sent }} sent{{ end }}">
+				{{ if $entry.Sent }}
+				<h4 class="media-heading">{{ $entry.Message.Subject }} <small>You replied on {{ $entry.Message.CreatedAt.Format "Jan 2, 2006 at 15:04 MST" }}. Only the recipient can decrypt it.</small></h4>
+				{{ else }}
+				{{ template "Message" $entry.Message }}
+				{{ end }}
+			</div>
+			{{ end }}
+			{{ if .ReplyTo }}
+			<div class="reply-form">
+				<form method="POST" action="/messages/{{ .ReplyTo.Id }}/reply" enctype="multipart/form-data" accept-charset="UTF-8">
+					<div class="form-group">
+						<label for="reply-form-subject">Subject</label>
+						<input class="form-control" type="text" id="reply-form-subject" name="{{ .SubjectFormFieldName }}" value="{{ if not .ReplyTo.SubjectEncrypted }}Re: {{ .ReplyTo.Subject }}{{ end }}">
+					</div>
+					<div class="form-group">
+						<label for="reply-form-message">Reply to {{ .ReplyTo.Sender.Name }}</label>
+						<textarea class="form-control" rows="5" id="reply-form-message" name="{{ .MessageFormFieldName }}"></textarea>
+					</div>
+					{{ template "MessageOptions" . }}
+					<div class="form-group rtxt">
+						<button class="btn btn-default" type="submit">Send Reply</button>
+					</div>
+				</form>
+			</div>
+			{{ end }}
+		</div>
+	</div>
+</div>
+{{ end }}
+{{ define "BodyAfterMain" }}{{ end }}
+`
+
+var threadTemplate *template.Template
+
+var vaultTemplateHtml = `
+{{ define "HeadHTML" }}{{ end }}
+{{ define "HeadCSS" }}
+#main { width: 900px; }
+.vault-credential { margin-bottom: 1em; }
+.vault-credential form { display: inline-block; margin: 0; }
+.vault-credential .btn { padding: 0.3em 8px; font-size: 0.8em; }
+.vault-form { padding: 20px; border: 1px solid #ccc; margin-bottom: 2em; }
+.alert.alert-danger { border-radius: 0; }
+{{ end }}
+{{ define "BodyMain" }}
+<div class="container-fluid tmargin">
+	<div class="row">
+		<div class="col-xs-12">
+			<h3>Vault <small><a href="/">Back to messages</a></small></h3>
+			<p>
+				Secrets in your vault are encrypted to each of your active keys.
+				The ciphers below can be decrypted with the key <code>{{ .KeyFingerprint }}</code>.
+			</p>
+			{{ if .Error }}<div class="alert alert-danger">{{ .Error }}</div>{{ end }}
+			<div class="vault-form">
+				<form method="POST" action="{{ .VaultURL }}" enctype="multipart/form-data" accept-charset="UTF-8">
+					<div class="form-group">
+						<label for="vault-form-key">Key</label>
+						<input class="form-control" type="text" id="vault-form-key" name="{{ .VaultKeyFormFieldName }}" placeholder="github-recovery-codes">
+					</div>
+					<div class="form-group">
+						<label for="vault-form-value">Value</label>
+						<textarea class="form-control" rows="3" id="vault-form-value" name="{{ .VaultValueFormFieldName }}"></textarea>
+					</div>
+					<div class="form-group">
+						<label for="vault-form-file">Or upload a file</label>
+						<input type="file" id="vault-form-file" name="{{ .VaultFileFormFieldName }}">
+					</div>
+					<div class="form-group rtxt">
+						<button class="btn btn-default" type="submit">Save to vault</button>
+					</div>
+				</form>
+			</div>
+			{{ if .Credentials }}
+				{{ range $index, $cred := .Credentials }}
+				<div class="vault-credential">
+					<h4>
+						{{ $cred.Key }}
+						<form method="POST" action="{{ $.VaultDeleteURL }}" onsubmit="return confirm('Delete {{ $cred.Key }} from your vault?');">
+							<input type="hidden" name="{{ $.VaultKeyFormFieldName }}" value="{{ $cred.Key }}">
+							<button class="btn btn-default" type="submit">Delete</button>
+						</form>
+					</h4>
+					{{ if $cred.Binary }}
+					<p class="help-block">This value is binary. Decrypt it and base64 decode the result to get it back.</p>
+					{{ end }}
+					<pre>{{ printf "%s" $cred.Cipher }}</pre>
+				</div>
+				{{ end }}
+			{{ else }}
+				<h4>Your vault is empty!</h4>
+			{{ end }}
+		</div>
+	</div>
+</div>
+{{ end }}
+{{ define "BodyAfterMain" }}{{ end }}
+`
+
+var vaultTemplate *template.Template
+
+var adminTemplateHtml = `
+{{ define "HeadHTML" }}{{ end }}
+{{ define "HeadCSS" }}
+#main { width: 900px; }
+.table > tbody > tr > td { vertical-align: middle; }
+.admin-actions form { display: inline-block; margin: 0; }
+.admin-actions .btn { padding: 0.3em 8px; font-size: 0.8em; }
+.key-fingerprint { font-size: 0.8em; }
+.alert.alert-danger { border-radius: 0; }
+{{ end }}
+{{ define "BodyMain" }}
+<div class="container-fluid tmargin">
+	<div class="row">
+		<div class="col-xs-12">
+			<h3>Users <small><a href="/">Back to messages</a></small></h3>
+			{{ if .Error }}<div class="alert alert-danger">{{ .Error }}</div>{{ end }}
+			<table class="table table-condensed">
+				<thead>
+					<tr>
+						<th>User</th>
+						<th>Type</th>
+						<th>Status</th>
+						<th>Keys</th>
+						<th></th>
+					</tr>
+				</thead>
+				<tbody>
+				{{ range $index, $row := .Users }}
+					<tr id="admin-user-{{ $row.User.Id }}">
+						<td>
+							{{ $row.User.Name }}
+							{{ if $row.User.IsAdmin }}<span class="label label-default">admin</span>{{ end }}
+							<br><small>{{ $row.User.Email }}</small>
+						</td>
+						<td>{{ $row.User.UserType }}</td>
+						<td>{{ if $row.User.IsSuspended }}suspended{{ else }}active{{ end }}</td>
+						<td>
+							{{ range $kindex, $key := $row.Keys }}
+								<div class="admin-actions">
+									<code class="key-fingerprint">{{ $key.Fingerprint }}</code>
+									{{ if not $row.IsCurrentUser }}
+									<form method="POST" action="/admin/keys/{{ $key.Id }}/delete">
+										<button class="btn btn-default" type="submit">Delete key</button>
+									</form>
+									{{ end }}
+								</div>
+							{{ end }}
+						</td>
+						<td class="admin-actions rtxt">
+							{{ if not $row.IsCurrentUser }}
+								{{ if $row.User.IsSuspended }}
+								<form method="POST" action="/admin/users/{{ $row.User.Id }}/reactivate">
+									<button class="btn btn-default" type="submit">Reactivate</button>
+								</form>
+								{{ else }}
+								<form method="POST" action="/admin/users/{{ $row.User.Id }}/suspend">
+									<button class="btn btn-default" type="submit">Suspend</button>
+								</form>
+								{{ end }}
+								<form method="POST" action="/admin/users/{{ $row.User.Id }}/offboard" onsubmit="return confirm('Offboard {{ $row.User.Email }}? This removes them from every project and revokes their keys.');">
+									<button class="btn btn-default" type="submit">Offboard</button>
+								</form>
+								<form method="POST" action="/admin/users/{{ $row.User.Id }}/delete" onsubmit="return confirm('Delete {{ $row.User.Email }} and all of their keys?');">
+									<button class="btn btn-danger" type="submit">Delete</button>
+								</form>
+							{{ end }}
+						</td>
+					</tr>
+				{{ end }}
+				</tbody>
+			</table>
+		</div>
+	</div>
+</div>
+{{ end }}
+{{ define "BodyAfterMain" }}{{ end }}
+`
+
+var adminTemplate *template.Template
+
+var offboardTemplateHtml = `
+{{ define "HeadHTML" }}{{ end }}
+{{ define "HeadCSS" }}
+#main { width: 900px; }
+{{ end }}
+{{ define "BodyMain" }}
+<div class="container-fluid tmargin">
+	<div class="row">
+		<div class="col-xs-12">
+			<h3>Offboarded {{ .User.Name }} <small>{{ .User.Email }}</small></h3>
+			<p>
+				The user has been removed from every project, their keys have been revoked and
+				their account has been suspended.
+			</p>
+			{{ if .Credentials }}
+			<p>They were able to decrypt the following credentials. Project owners should rotate them.</p>
+			<table class="table table-condensed">
+				<thead>
+					<tr>
+						<th>Project</th>
+						<th>Environment</th>
+						<th>Credential</th>
+					</tr>
+				</thead>
+				<tbody>
+				{{ range $index, $cred := .Credentials }}
+					<tr>
+						<td>{{ $cred.ProjectName }} <small>(ID = {{ $cred.ProjectId }})</small></td>
+						<td>{{ $cred.Environment }}</td>
+						<td><code>{{ $cred.Key }}</code></td>
+					</tr>
+				{{ end }}
+				</tbody>
+			</table>
+			{{ else }}
+			<p>They did not have access to any project credentials.</p>
+			{{ end }}
+			<p><a href="{{ .AdminURL }}">Back to users</a></p>
+		</div>
+	</div>
+</div>
+{{ end }}
+{{ define "BodyAfterMain" }}{{ end }}
+`
+
+var offboardTemplate *template.Template
+
 func init() {
 	var err error
 
@@ -511,7 +945,7 @@ func init() {
 		panic(err)
 	}
 
-	messageTemplate, err = template.New("message").Parse(`{{ template "Message" . }}`)
+	messageTemplate, err = template.New("message").Parse(`{{ template "Message" .Message }}`)
 	if err != nil {
 		panic(err)
 	}
@@ -520,11 +954,40 @@ func init() {
 		panic(err)
 	}
 
-	messageTextTemplate, err = textTemplate.New("messageText").Parse(`{{ template "Message" . }}`)
+	openMessageTemplate, err = template.Must(baseTemplate.Clone()).Parse(openMessageTemplateHtml)
+	if err != nil {
+		panic(err)
+	}
+
+	threadTemplate, err = template.Must(baseTemplate.Clone()).Parse(threadTemplateHtml)
+	if err != nil {
+		panic(err)
+	}
+	threadTemplate, err = threadTemplate.Parse(messageTemplateHtml)
 	if err != nil {
 		panic(err)
 	}
-	messageTextTemplate, err = messageTextTemplate.Parse(messageTemplateText)
+	threadTemplate, err = threadTemplate.Parse(userTemplateHtml)
+	if err != nil {
+		panic(err)
+	}
+
+	vaultTemplate, err = template.Must(baseTemplate.Clone()).Parse(vaultTemplateHtml)
+	if err != nil {
+		panic(err)
+	}
+
+	adminTemplate, err = template.Must(baseTemplate.Clone()).Parse(adminTemplateHtml)
+	if err != nil {
+		panic(err)
+	}
+
+	offboardTemplate, err = template.Must(baseTemplate.Clone()).Parse(offboardTemplateHtml)
+	if err != nil {
+		panic(err)
+	}
+
+	notificationTemplate, err = template.New("notification").Parse(notificationTemplateHtml)
 	if err != nil {
 		panic(err)
 	}
@@ -0,0 +1,156 @@
+package web
+
+import (
+	"errors"
+	"github.com/rajivnavada/cryptzd/crypto"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	VaultURL       = "/vault"
+	VaultDeleteURL = "/vault/delete"
+
+	VaultKeyFormFieldName   = "key"
+	VaultValueFormFieldName = "value"
+	VaultFileFormFieldName  = "value_file"
+)
+
+var (
+	MissingVaultKeyError   = errors.New("POST data does not contain a vault key")
+	MissingVaultValueError = errors.New("POST data does not contain a vault value")
+)
+
+func GetVault(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	u, err := sess.User(dbMap)
+	if !assertErrorIsNil(w, err, "Error getting current logged in user") {
+		return
+	}
+
+	// Only the ciphers for the key used in this session are shown
+	creds, err := u.VaultCredentials(sess.KeyId, dbMap)
+	if !assertErrorIsNil(w, err, "Error extracting vault credentials") {
+		return
+	}
+
+	templateDefs := newTemplateArgs()
+	templateDefs.ShowHeader = false
+	templateDefs.Extensions = &struct {
+		Credentials             []crypto.UserCredential
+		KeyFingerprint          string
+		VaultURL                string
+		VaultDeleteURL          string
+		VaultKeyFormFieldName   string
+		VaultValueFormFieldName string
+		VaultFileFormFieldName  string
+		Error                   string
+	}{
+		Credentials:             creds,
+		KeyFingerprint:          sess.KeyFingerprint,
+		VaultURL:                VaultURL,
+		VaultDeleteURL:          VaultDeleteURL,
+		VaultKeyFormFieldName:   VaultKeyFormFieldName,
+		VaultValueFormFieldName: VaultValueFormFieldName,
+		VaultFileFormFieldName:  VaultFileFormFieldName,
+		Error:                   r.URL.Query().Get("error"),
+	}
+
+	if err := vaultTemplate.Execute(w, templateDefs); err != nil {
+		panic(err)
+	}
+}
+
+func PostVault(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	key := strings.TrimSpace(r.FormValue(VaultKeyFormFieldName))
+	value := r.FormValue(VaultValueFormFieldName)
+	// An uploaded file replaces the typed value. Files can hold binary data like keystores.
+	if f, _, err := r.FormFile(VaultFileFormFieldName); err == nil {
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if !assertErrorIsNil(w, err, "Error reading uploaded vault file") {
+			return
+		}
+		value = string(data)
+	}
+	if key == "" {
+		redirectToVaultWithError(w, r, MissingVaultKeyError)
+		return
+	}
+	if value == "" {
+		redirectToVaultWithError(w, r, MissingVaultValueError)
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	u, err := sess.User(dbMap)
+	if !assertErrorIsNil(w, err, "Error getting current logged in user") {
+		return
+	}
+
+	if err = u.SetVaultCredential(key, value, dbMap); err != nil {
+		logError(err, "Error setting vault credential with key "+key)
+		redirectToVaultWithError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, VaultURL, http.StatusSeeOther)
+}
+
+func PostVaultDelete(w http.ResponseWriter, r *http.Request) {
+	sess := mustBeAuthenticated(w, r)
+	if sess == nil {
+		return
+	}
+
+	key := strings.TrimSpace(r.FormValue(VaultKeyFormFieldName))
+	if key == "" {
+		redirectToVaultWithError(w, r, MissingVaultKeyError)
+		return
+	}
+
+	dbMap, err := crypto.NewDataMapper()
+	if !assertErrorIsNil(w, err, "Error creating instance of crypto.DataMapper") {
+		return
+	}
+	defer dbMap.Close()
+
+	u, err := sess.User(dbMap)
+	if !assertErrorIsNil(w, err, "Error getting current logged in user") {
+		return
+	}
+
+	if err = u.RemoveVaultCredential(key, dbMap); err != nil {
+		logError(err, "Error deleting vault credential with key "+key)
+		redirectToVaultWithError(w, r, err)
+		return
+	}
+
+	http.Redirect(w, r, VaultURL, http.StatusSeeOther)
+}
+
+func redirectToVaultWithError(w http.ResponseWriter, r *http.Request, err error) {
+	http.Redirect(w, r, VaultURL+"?"+url.Values{"error": {err.Error()}}.Encode(), http.StatusSeeOther)
+}
@@ -11,7 +11,9 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/rajivnavada/cryptzd/crypto"
 	"github.com/rajivnavada/cryptzd/mail"
+	"io/ioutil"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -26,15 +28,24 @@ const (
 	ActivateURLBase      = "/activate/"
 	WebSocketURL         = "/ws"
 
-	PublicKeyFormFieldName = "public_key"
-	UserIdFormFieldName    = "user_id"
-	SubjectFormFieldName   = "subject"
-	MessageFormFieldName   = "message"
+	PublicKeyFormFieldName      = "public_key"
+	UserIdFormFieldName         = "user_id"
+	ProjectIdFormFieldName      = "project_id"
+	SubjectFormFieldName        = "subject"
+	MessageFormFieldName        = "message"
+	TTLFormFieldName            = "ttl"
+	ViewOnceFormFieldName       = "view_once"
+	EncryptSubjectFormFieldName = "encrypt_subject"
+	AttachmentFormFieldName     = "attachment"
 )
 
 var (
 	MissingUserIdError  = errors.New("POST data does not contain a valid userId field")
 	MissingMessageError = errors.New("POST data does not contain a message")
+	MissingProjectError = errors.New("POST data does not contain a project you are a member of")
+	InvalidTTLError     = errors.New("POST data contains an invalid time to live. Use a duration like 30m or 24h.")
+	UserSuspendedError  = errors.New("This account has been suspended. Please contact a server administrator.")
+	InactiveKeyError    = errors.New("This key has not been activated, has expired or has been revoked.")
 )
 
 func GetLogin(w http.ResponseWriter, r *http.Request) {
@@ -211,16 +222,27 @@ func Activation(w http.ResponseWriter, r *http.Request) {
 
 	// If the user was newly activated we need to broadcast it to others
 	if key.ActivatedAt().After(startTime) {
-		H.broadcastUser <- messagesTemplateExtensions{
-			Session:              nil,
-			Messages:             nil,
-			Users:                nil,
-			CurrentUser:          currentUser,
-			FormActionName:       buildUrl(r, IndexURL, ""),
-			UserIdFormFieldName:  UserIdFormFieldName,
-			SubjectFormFieldName: SubjectFormFieldName,
-			MessageFormFieldName: MessageFormFieldName,
-			WebSocketURL:         "",
+		p, err := renderUserActivated(messagesTemplateExtensions{
+			Session:                     nil,
+			Messages:                    nil,
+			Users:                       nil,
+			CurrentUser:                 currentUser,
+			FormActionName:              buildUrl(r, IndexURL, ""),
+			UserIdFormFieldName:         UserIdFormFieldName,
+			SubjectFormFieldName:        SubjectFormFieldName,
+			MessageFormFieldName:        MessageFormFieldName,
+			TTLFormFieldName:            TTLFormFieldName,
+			ViewOnceFormFieldName:       ViewOnceFormFieldName,
+			EncryptSubjectFormFieldName: EncryptSubjectFormFieldName,
+			AttachmentFormFieldName:     AttachmentFormFieldName,
+			MaxAttachmentSize:           crypto.MaxAttachmentSize,
+			EncryptSubjects:             crypto.EncryptSubjects,
+			WebSocketURL:                "",
+		})
+		if err != nil {
+			logError(err, "Error constructing user activation")
+		} else {
+			broadcast(envelope{User: &p})
 		}
 	}
 
@@ -258,8 +280,14 @@ func Websocket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := newConnection(wsConn, userId(uid), publicKeyId(sess.KeyId), fingerprint(sess.KeyFingerprint), false)
-	H.register <- c
+	c := newConnection(wsConn, userId(uid), publicKeyId(sess.KeyId), fingerprint(sess.KeyFingerprint), false, false)
+	select {
+	case H.register <- c:
+	case <-H.done:
+		c.goAway()
+		wsConn.Close()
+		return
+	}
 
 	go c.writePump()
 	c.readPump()
@@ -282,7 +310,34 @@ func WebsocketWithFingerprint(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Get the userId from the key
-	uid := key.User(dbMap).Id()
+	u := key.User(dbMap)
+	if u == nil {
+		http.Error(w, "Unknown key", http.StatusForbidden)
+		return
+	}
+	uid := u.Id()
+
+	// Suspended users and keys that can't sign in are not allowed to register new connections
+	if err = checkKey(key, u); err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	// Anyone can send a fingerprint, an API token proves the client holds the private key. Admin operations need one.
+	token := apiToken(r)
+	if token != "" {
+		t, err := crypto.FindAPIToken(token, dbMap)
+		if err == nil && t.PublicKeyId() != key.Id() {
+			err = crypto.APITokenNotFoundError
+		}
+		if err == crypto.APITokenNotFoundError {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if !assertErrorIsNil(w, err, "Error finding API token") {
+			return
+		}
+	}
 
 	// Upgrades the connection to a websocket connection and registers the user in a users map
 	wsConn, err := upgrader.Upgrade(w, r, nil)
@@ -290,13 +345,24 @@ func WebsocketWithFingerprint(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	c := newConnection(wsConn, userId(uid), publicKeyId(key.Id()), fingerprint(fpr), true)
-	H.register <- c
-
+	// The connection is registered with the hub once the CLI says HELLO
+	c := newConnection(wsConn, userId(uid), publicKeyId(key.Id()), fingerprint(fpr), true, u.IsServiceAccount())
+	c.apiToken = token
 	go c.writePump()
 	c.readPump()
 }
 
+// checkKey returns why the key can't be used to authenticate as u, or nil if it can
+func checkKey(key crypto.PublicKey, u crypto.User) error {
+	if !key.Active() || key.Expired() || key.Revoked() {
+		return InactiveKeyError
+	}
+	if u.IsSuspended() {
+		return UserSuspendedError
+	}
+	return nil
+}
+
 func GetMessages(w http.ResponseWriter, r *http.Request) {
 	// Checks if the user is logged in or not. If not logged in redirect to login page
 	sess := mustBeAuthenticated(w, r)
@@ -317,34 +383,168 @@ func GetMessages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Get the message collection and use it to render template of user messages
-	mc, err := key.Messages(dbMap)
+	// Get a page of the message collection and use it to render template of user messages
+	filter, inbox := newMessageFilter(r.URL.Query())
+	mc, total, err := key.FindMessages(filter, dbMap)
 	if !assertErrorIsNil(w, err, "Error extracting messages for a key") {
 		return
 	}
+	inbox.setTotal(total, r.URL.Query())
 
 	uc, err := crypto.FindAllUsers(dbMap)
 	if !assertErrorIsNil(w, err, "Error extracting all users") {
 		return
 	}
 
+	projects, err := crypto.FindProjectsForUser(sess.UserId, dbMap)
+	if !assertErrorIsNil(w, err, "Error extracting projects for user") {
+		return
+	}
+
+	// Server administrators get a link to the user management page
+	adminURL := ""
+	if currentUser, err := sess.User(dbMap); err == nil && currentUser.IsAdmin() {
+		adminURL = AdminURL
+	}
+
 	templateDefs := newTemplateArgs()
 	templateDefs.ShowHeader = false
 	templateDefs.Extensions = &messagesTemplateExtensions{
-		Session:              sess,
-		Messages:             mc,
-		Users:                uc,
-		FormActionName:       buildUrl(r, IndexURL, ""),
-		UserIdFormFieldName:  UserIdFormFieldName,
-		SubjectFormFieldName: SubjectFormFieldName,
-		MessageFormFieldName: MessageFormFieldName,
-		WebSocketURL:         buildWebSocketUrl(r, WebSocketURL),
+		Session:                     sess,
+		Messages:                    mc,
+		Inbox:                       inbox,
+		Users:                       uc,
+		Projects:                    projects,
+		FormActionName:              buildUrl(r, IndexURL, ""),
+		UserIdFormFieldName:         UserIdFormFieldName,
+		ProjectIdFormFieldName:      ProjectIdFormFieldName,
+		SubjectFormFieldName:        SubjectFormFieldName,
+		MessageFormFieldName:        MessageFormFieldName,
+		TTLFormFieldName:            TTLFormFieldName,
+		ViewOnceFormFieldName:       ViewOnceFormFieldName,
+		EncryptSubjectFormFieldName: EncryptSubjectFormFieldName,
+		AttachmentFormFieldName:     AttachmentFormFieldName,
+		MaxAttachmentSize:           crypto.MaxAttachmentSize,
+		EncryptSubjects:             crypto.EncryptSubjects,
+		WebSocketURL:                buildWebSocketUrl(r, WebSocketURL),
+		AdminURL:                    adminURL,
 	}
 
 	// Execute the template and return
 	messagesTemplate.Execute(w, templateDefs)
 }
 
+// findMessageRecipients looks up the users a message is addressed to. At least one user is required.
+func findMessageRecipients(userIdStrs []string, dbMap crypto.DataMapper) ([]crypto.User, error) {
+	var ret []crypto.User
+	for _, userIdStr := range userIdStrs {
+		userId, err := strconv.Atoi(strings.TrimSpace(userIdStr))
+		if err != nil {
+			return nil, MissingUserIdError
+		}
+		u, err := crypto.FindUserWithId(userId, dbMap)
+		if err != nil {
+			return nil, err
+		}
+		if u.Id() == 0 {
+			return nil, fmt.Errorf("Could not find user with Id %d", userId)
+		}
+		ret = append(ret, u)
+	}
+	if len(ret) == 0 {
+		return nil, MissingUserIdError
+	}
+	return ret, nil
+}
+
+// findMessageProject looks up the project a message is addressed to. Only members can message a project.
+func findMessageProject(projectIdStr string, senderId int, dbMap crypto.DataMapper) (crypto.Project, error) {
+	projectId, err := strconv.Atoi(projectIdStr)
+	if err != nil {
+		return nil, MissingProjectError
+	}
+	p, err := crypto.FindProjectWithId(projectId, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	if p.Id() == 0 || !p.HasMemberWithUserId(senderId, dbMap) {
+		return nil, MissingProjectError
+	}
+	return p, nil
+}
+
+// messageOptionsFromRequest reads the optional time to live, view once, encrypt subject and attachment fields of a message form
+func messageOptionsFromRequest(r *http.Request) (crypto.MessageOptions, error) {
+	opts := crypto.MessageOptions{
+		ViewOnce:       r.FormValue(ViewOnceFormFieldName) != "",
+		EncryptSubject: r.FormValue(EncryptSubjectFormFieldName) != "",
+	}
+	if ttlStr := strings.TrimSpace(r.FormValue(TTLFormFieldName)); ttlStr != "" {
+		ttl, err := time.ParseDuration(ttlStr)
+		if err != nil || ttl <= 0 {
+			return opts, InvalidTTLError
+		}
+		opts.TTL = ttl
+	}
+	attachments, err := attachmentsFromRequest(r)
+	if err != nil {
+		return opts, err
+	}
+	opts.Attachments = attachments
+	return opts, nil
+}
+
+// attachmentsFromRequest reads the files uploaded with a multipart message form
+func attachmentsFromRequest(r *http.Request) ([]crypto.AttachmentFile, error) {
+	// FormValue has already parsed the multipart form if there is one
+	if r.MultipartForm == nil {
+		return nil, nil
+	}
+	var ret []crypto.AttachmentFile
+	var size int64
+	for _, fh := range r.MultipartForm.File[AttachmentFormFieldName] {
+		// Check the size before reading the file into memory
+		size += fh.Size
+		if size > crypto.MaxAttachmentSize {
+			return nil, crypto.AttachmentTooLargeError
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, crypto.AttachmentFile{
+			Filename:    filepath.Base(fh.Filename),
+			ContentType: fh.Header.Get("Content-Type"),
+			Data:        data,
+		})
+	}
+	return ret, nil
+}
+
+// sendMessage encrypts the message for the project or the users, saves it and delivers it to connected recipients
+func sendMessage(sender crypto.User, toProject crypto.Project, toUsers []crypto.User, message, subject string, opts crypto.MessageOptions, dbMap crypto.DataMapper) (map[string]crypto.EncryptedMessage, error) {
+	var encryptedMessages map[string]crypto.EncryptedMessage
+	var err error
+	switch {
+	case toProject != nil:
+		encryptedMessages, err = toProject.EncryptAndSave(sender, message, subject, opts, dbMap)
+	case len(toUsers) == 1:
+		encryptedMessages, err = toUsers[0].EncryptAndSave(sender, message, subject, opts, dbMap)
+	default:
+		encryptedMessages, err = crypto.EncryptAndSaveForUsers(sender, toUsers, message, subject, opts, dbMap)
+	}
+	if err != nil {
+		return nil, err
+	}
+	queueMessages(encryptedMessages, dbMap)
+	return encryptedMessages, nil
+}
+
 func PostMessage(w http.ResponseWriter, r *http.Request) {
 	// Checks if the user is logged in or not. If not logged in redirect to login page
 	sess := mustBeAuthenticated(w, r)
@@ -366,19 +566,6 @@ func PostMessage(w http.ResponseWriter, r *http.Request) {
 		errs = append(errs, err.Error())
 	}
 
-	// Check userId
-	userIdStr := strings.TrimSpace(r.FormValue(UserIdFormFieldName))
-	if userIdStr == "" {
-		logError(MissingUserIdError, "No userId in request")
-		errs = append(errs, MissingUserIdError.Error())
-	}
-
-	userId, err := strconv.Atoi(userIdStr)
-	if err != nil {
-		logError(MissingUserIdError, "Could not convert userId to int")
-		errs = append(errs, MissingUserIdError.Error())
-	}
-
 	// Check message
 	message := strings.TrimSpace(r.FormValue(MessageFormFieldName))
 	if message == "" {
@@ -389,19 +576,34 @@ func PostMessage(w http.ResponseWriter, r *http.Request) {
 	// Subject can be empty
 	subject := strings.TrimSpace(r.FormValue(SubjectFormFieldName))
 
-	toUser, err := crypto.FindUserWithId(userId, dbMap)
+	// Time to live and view once are optional
+	opts, err := messageOptionsFromRequest(r)
 	if err != nil {
-		logError(err, fmt.Sprintf("Could not find user with Id %d", userId))
+		logError(err, "Could not parse message options")
 		errs = append(errs, err.Error())
 	}
 
-	if len(errs) == 0 {
-		encryptedMessages, err := toUser.EncryptAndSave(sender, message, subject, dbMap)
+	// A message goes either to every member of a project or to one or more users
+	var toProject crypto.Project
+	var toUsers []crypto.User
+	if projectIdStr := strings.TrimSpace(r.FormValue(ProjectIdFormFieldName)); projectIdStr != "" {
+		toProject, err = findMessageProject(projectIdStr, sess.UserId, dbMap)
 		if err != nil {
-			logError(err, "Error occured when encrypting message for user")
+			logError(err, "Could not find project "+projectIdStr)
+			errs = append(errs, err.Error())
+		}
+	} else {
+		toUsers, err = findMessageRecipients(r.Form[UserIdFormFieldName], dbMap)
+		if err != nil {
+			logError(err, "Could not find recipients")
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(errs) == 0 {
+		if _, err := sendMessage(sender, toProject, toUsers, message, subject, opts, dbMap); err != nil {
+			logError(err, "Error occured when encrypting message for recipients")
 			errs = append(errs, err.Error())
-		} else {
-			H.broadcastMessage <- encryptedMessages
 		}
 	}
 
@@ -428,8 +630,26 @@ func Router() http.Handler {
 	r.HandleFunc(PendingActivationURL, NeedActivationMessage).Methods("GET")
 	r.HandleFunc("/activate/{token}", Activation).Methods("GET")
 	r.HandleFunc("/logout", Logout).Methods("GET")
+	r.HandleFunc(MessagesURLBase+"{messageId}/thread", GetMessageThread).Methods("GET")
+	r.HandleFunc(MessagesURLBase+"{messageId}/reply", PostMessageReply).Methods("POST")
+	r.HandleFunc(MessagesURLBase+"{messageId}/open", PostMessageOpen).Methods("POST")
+	r.HandleFunc(MessagesURLBase+"{messageId}/read", PostMessageRead).Methods("POST")
+	r.HandleFunc(MessagesURLBase+"{messageId}/unread", PostMessageUnread).Methods("POST")
+	r.HandleFunc(MessagesURLBase+"{messageId}/delete", PostMessageDelete).Methods("POST")
+	r.HandleFunc(MessagesURLBase+"{messageId}/attachments/{attachmentId}", GetMessageAttachment).Methods("GET")
+	r.HandleFunc(VaultURL, GetVault).Methods("GET")
+	r.HandleFunc(VaultURL, PostVault).Methods("POST")
+	r.HandleFunc(VaultDeleteURL, PostVaultDelete).Methods("POST")
+	r.HandleFunc(AdminURL, GetAdmin).Methods("GET")
+	r.HandleFunc(AdminURL+"/metrics", GetAdminMetrics).Methods("GET")
+	r.HandleFunc("/admin/users/{userId}/suspend", PostAdminSuspendUser).Methods("POST")
+	r.HandleFunc("/admin/users/{userId}/reactivate", PostAdminReactivateUser).Methods("POST")
+	r.HandleFunc("/admin/users/{userId}/delete", PostAdminDeleteUser).Methods("POST")
+	r.HandleFunc("/admin/users/{userId}/offboard", PostAdminOffboardUser).Methods("POST")
+	r.HandleFunc("/admin/keys/{keyId}/delete", PostAdminDeleteKey).Methods("POST")
 	r.HandleFunc("/ws/{fingerprint}", WebsocketWithFingerprint)
 	r.HandleFunc("/ws", Websocket)
+	addAPIRoutes(r)
 
 	return r
 }
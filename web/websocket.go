@@ -2,12 +2,14 @@ package web
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/websocket"
-	pb "github.com/rajivnavada/cryptz_pb"
 	"github.com/rajivnavada/cryptzd/crypto"
+	pb "github.com/rajivnavada/cryptzd/cryptz_pb"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -27,13 +29,48 @@ const (
 
 	// Maximum message size allowed from peer.
 	maxMessageSize = 4096
+
+	// Pushes and replies queued for a connection before its overflow policy kicks in
+	sendQueueSize = 256
+
+	// Largest chunk of a credential cipher sent back in a single frame
+	credentialChunkSize = maxMessageSize / 2
+
+	// Operations a single connection can have in flight at once
+	maxConcurrentOps = 8
+
+	// Time allowed for an operation before it is answered with ErrOperationTimedOut. Clients can ask for less.
+	operationTimeout = 60 * time.Second
+
+	// Uploads and attachments left alone this long are dropped
+	uploadTTL = 10 * time.Minute
 )
 
+// Bytes a connection can hold in uploads and attachments waiting for a message. Enough for the attachments of one
+// message and a credential value.
+var maxUploadSize = crypto.MaxAttachmentSize + crypto.MaxCredentialSize
+
 var (
-	ErrDuplicateFingerprint       = errors.New("New connection attempted with duplicate fingerprint. Selecting new connection over old.")
 	ErrInvalidArgsForProjectOp    = errors.New("Project operation received invalid arguments. Please make sure all required arguments are provided.")
 	ErrInvalidArgsForCredentialOp = errors.New("Credential operation received invalid arguments. Please make sure all required arguments are provided.")
+	ErrInvalidArgsForAdminOp      = errors.New("Admin operation received invalid arguments. Please make sure all required arguments are provided.")
+	ErrInvalidArgsForVaultOp      = errors.New("Vault operation received invalid arguments. Please make sure all required arguments are provided.")
+	ErrInvalidArgsForMessageOp    = errors.New("Message operation received invalid arguments. Please make sure all required arguments are provided.")
+	ErrInvalidArgsForEventOp      = errors.New("Event operation received invalid arguments. Please make sure all required arguments are provided.")
 	ErrNoAccess                   = errors.New("You do not have permission to perform this operation.")
+	ErrChunkOutOfOrder            = errors.New("Chunk does not continue the upload in progress. Restart the upload from offset 0.")
+	ErrUploadsTooLarge            = errors.New("Uploads on this connection are too large. Send the attachments already uploaded before uploading more.")
+	ErrTooManyOperations          = errors.New("Too many operations in flight on this connection. Wait for some of them to finish and try again.")
+	ErrDuplicateOpId              = errors.New("An operation with the same opId is already in flight.")
+	ErrUnknownOperation           = errors.New("No operation with that opId is in flight.")
+	ErrOperationTimedOut          = errors.New("Operation timed out. It may still complete on the server.")
+	ErrOperationCancelled         = errors.New("Operation was cancelled. It may still complete on the server.")
+	ErrShuttingDown               = errors.New("Server is shutting down. Reconnect and try again in a moment.")
+	ErrUnsupportedOperation       = errors.New("This operation is not supported by the server. Check the capabilities returned by HELLO.")
+	ErrHelloRequired              = errors.New("This client did not say HELLO. Please upgrade to the latest version of the CLI.")
+	ErrClientTooOld               = errors.New("This version of the CLI is no longer supported. Please upgrade to the latest version.")
+	ErrDuplicateHello             = errors.New("HELLO was already received on this connection.")
+	ErrAuthenticationRequired     = errors.New("This operation needs proof that you hold the private key. Connect with an API token in the Authorization header.")
 )
 
 var upgrader = websocket.Upgrader{
@@ -45,115 +82,16 @@ type fingerprint string
 type publicKeyId int
 type userId int
 
-// hub maintains the set of active connections and broadcasts messages to the
-// connections.
-type Hub struct {
-	// Registered connections.
-	connections map[fingerprint]*connection
-
-	// Channel to broadcast messages to connected users
-	broadcastMessage chan map[string]crypto.EncryptedMessage
-
-	// Channel to broadcast new user activations
-	broadcastUser chan messagesTemplateExtensions
-
-	// Register requests from the connections.
-	register chan *connection
-
-	// Unregister requests from connections.
-	unregister chan *connection
-}
-
-var H = Hub{
-	broadcastMessage: make(chan map[string]crypto.EncryptedMessage),
-	broadcastUser:    make(chan messagesTemplateExtensions),
-	register:         make(chan *connection),
-	unregister:       make(chan *connection),
-	connections:      make(map[fingerprint]*connection),
-}
-
-// Run makes the hub ready to receive / broadcast connections
-func (h *Hub) Run() {
-	// NOTE: the reason the delete's don't need to be guarded by a mutex here is because each
-	//       'case' is handled synchronously.
-	for {
-		select {
-		case c := <-h.register:
-			// If we are trying to register a connection for an existing fingerprint,
-			// close that connection first
-			if oldC, ok := h.connections[c.fingerprint]; ok {
-				logError(ErrDuplicateFingerprint, "Error maintaining connection with duplicate key")
-				delete(h.connections, c.fingerprint)
-				oldC.closeChan()
-			}
-			h.connections[c.fingerprint] = c
-
-		case c := <-h.unregister:
-			if _, ok := h.connections[c.fingerprint]; ok {
-				delete(h.connections, c.fingerprint)
-				c.closeChan()
-			}
-
-		case messages := <-h.broadcastMessage:
-			// m is a map of fingerprint to message
-			for k, m := range messages {
-				// For each key, find if we have an active connection
-				if c, ok := h.connections[fingerprint(k)]; ok {
-					// Prepare a bytes buffer to collect the output
-					buf := &bytes.Buffer{}
-					var err error
-					if c.isCLI {
-						err = messageTextTemplate.Execute(buf, m)
-					} else {
-						err = messageTemplate.Execute(buf, m)
-					}
-					// If there is an active connection, send message
-					if err != nil {
-						logError(err, "Error constructing message HTML")
-					} else {
-						select {
-						case c.send <- buf.Bytes():
-						default:
-							delete(h.connections, fingerprint(k))
-							c.closeChan()
-						}
-					}
-				}
-			}
+// overflowPolicy decides what happens to a push when the send queue of a connection is full
+type overflowPolicy int
 
-		case user := <-h.broadcastUser:
-			// Prepare a bytes buffer to collect the output
-			buf := &bytes.Buffer{}
-			// If there is an active connection, send message
-			if err := userTemplate.Execute(buf, user); err != nil {
-				logError(err, "Error constructing user HTML")
-			} else {
-				for k, c := range h.connections {
-					select {
-					case c.send <- buf.Bytes():
-					default:
-						delete(h.connections, fingerprint(k))
-						c.closeChan()
-					}
-				}
-			}
-		}
-	}
-}
+const (
+	// overflowDisconnect drops the connection. CLI clients get anything they missed replayed when they reconnect.
+	overflowDisconnect overflowPolicy = iota
 
-// Close closes all open connections and destroys the hub
-func (h *Hub) Close() {
-	// closes all open connections
-	// Loops over all connenctions and closes connections
-	for _, c := range h.connections {
-		h.unregister <- c
-	}
-	// Also closes the broadcast channels
-	close(h.broadcastMessage)
-	close(h.broadcastUser)
-	close(h.register)
-	close(h.unregister)
-}
+	// overflowDrop drops the push and keeps the connection. Browsers pick up what they missed on the next page load.
+	overflowDrop
+)
 
 // connection is an middleman between the websocket connection and the hub.
 type connection struct {
@@ -164,6 +102,11 @@ type connection struct {
 	lock sync.Locker
 	// Buffered channel of outbound messages.
 	send chan []byte
+	// Responses to operations. They have their own queue so pushes can't crowd them out. It is never closed,
+	// replies give up once done is closed instead.
+	replies chan []byte
+	// Closed along with send
+	done chan struct{}
 	// Records if this connection is closed
 	closed bool
 
@@ -176,6 +119,43 @@ type connection struct {
 	fingerprint fingerprint
 
 	isCLI bool
+
+	// Service accounts only get read access to the projects they were added to
+	isServiceAccount bool
+
+	// API token the connection was opened with, if any. It proves the client holds the private key of the key.
+	apiToken string
+
+	// What happens to pushes once the send queue is full
+	overflow overflowPolicy
+
+	// Protocol version agreed on with HELLO, 0 until then. Only readPump touches it.
+	protocolVersion int32
+
+	// Projects the connection is subscribed to. Only the hub touches it.
+	projects []int
+
+	// Set until the events the key missed are queued. The hub holds back live pushes until then. Only the hub
+	// touches these once the connection is registered.
+	replaying bool
+	held      [][]byte
+	// Set when the events the key missed didn't all fit in the send queue. Events are left for the next connection
+	// to replay, so the client never acknowledges past one it didn't get.
+	behind bool
+
+	// Credential values and attachments being uploaded in chunks, keyed by where they will be stored
+	uploads map[string]*upload
+	// Attachments uploaded in chunks, by filename, until a message is sent with them
+	attachments map[string]*uploadedAttachment
+	// Protects uploads and attachments. Chunks of an upload still have to be sent one at a time, waiting for the response to each.
+	uploadsLock sync.Mutex
+
+	// One slot per operation in flight
+	slots chan struct{}
+	// Cancels the operations in flight, by opId
+	operations map[int32]context.CancelFunc
+	// Protects operations
+	opsLock sync.Mutex
 }
 
 func (c *connection) closeChan() {
@@ -185,19 +165,25 @@ func (c *connection) closeChan() {
 	if c.closed {
 		return
 	}
-	c.ws.Close()
+	// Connections of the API and the gRPC service have no websocket
+	if c.ws != nil {
+		c.ws.Close()
+	}
 	close(c.send)
+	close(c.done)
 	c.closed = true
 }
 
 // readPump pumps messages from the websocket connection to the hub.
 func (c *connection) readPump() {
 	defer func() {
+		c.cancelOperations()
 		// unregister should also close the channel
 		// no need to call closeChan here
-		H.unregister <- c
+		H.drop(c)
 	}()
 
+	// Attachments larger than a message are uploaded in chunks with UPLOAD_ATTACHMENT_CHUNK
 	c.ws.SetReadLimit(maxMessageSize)
 	c.ws.SetReadDeadline(time.Now().Add(pongWait))
 	c.ws.SetPongHandler(func(string) error { c.ws.SetReadDeadline(time.Now().Add(pongWait)); return nil })
@@ -223,144 +209,752 @@ func (c *connection) readPump() {
 			continue
 		}
 
-		projectOp := opQuery.GetProjectOp()
-		result := &pb.Response{
-			Status: pb.Response_ERROR,
-			Error:  "This operation is temporarily unsupported",
+		// The handshake is answered right away, and has to come before anything else from a CLI client
+		if opQuery.Hello != nil {
+			if c.hello(opQuery) {
+				c.startPushes()
+			}
+			continue
+		}
+		if c.isCLI && c.protocolVersion == 0 {
+			c.reply(errorResponse(opQuery.OpId, ErrHelloRequired))
+			continue
+		}
+
+		// Cancellations are handled right away so they never wait behind the operation they cancel
+		if opQuery.CancelOpId != 0 {
+			c.reply(c.cancelOperation(opQuery))
+			continue
+		}
+
+		// Every other operation runs in its own goroutine so a slow one doesn't hold up the rest
+		ctx, cancel, err := c.startOperation(opQuery)
+		if err != nil {
+			c.reply(errorResponse(opQuery.OpId, err))
+			continue
+		}
+		go c.dispatch(ctx, cancel, opQuery)
+	}
+}
+
+// startOperation reserves one of the operation slots of the connection and registers the operation so it can be cancelled.
+// The returned context is done once the operation times out or is cancelled.
+func (c *connection) startOperation(opQuery *pb.Operation) (context.Context, context.CancelFunc, error) {
+	c.opsLock.Lock()
+	defer c.opsLock.Unlock()
+
+	if _, ok := c.operations[opQuery.OpId]; ok && opQuery.OpId != 0 {
+		return nil, nil, ErrDuplicateOpId
+	}
+	select {
+	case c.slots <- struct{}{}:
+	default:
+		return nil, nil, ErrTooManyOperations
+	}
+	if err := H.startOperation(); err != nil {
+		<-c.slots
+		return nil, nil, err
+	}
+
+	timeout := operationTimeout
+	if t := time.Duration(opQuery.Timeout) * time.Second; t > 0 && t < timeout {
+		timeout = t
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	// Operations without an id can't be cancelled by the client
+	if opQuery.OpId != 0 {
+		c.operations[opQuery.OpId] = cancel
+	}
+	return ctx, cancel, nil
+}
+
+// dispatch performs the operation and replies with its result, or with an error if it times out or is cancelled first.
+// The operations don't stop half way, so an abandoned operation still runs to completion and holds its slot until then.
+func (c *connection) dispatch(ctx context.Context, cancel context.CancelFunc, opQuery *pb.Operation) {
+	defer func() {
+		cancel()
+		if opQuery.OpId != 0 {
+			c.opsLock.Lock()
+			delete(c.operations, opQuery.OpId)
+			c.opsLock.Unlock()
+		}
+	}()
+
+	done := make(chan *pb.Response, 1)
+	go func() {
+		defer func() {
+			<-c.slots
+			H.inFlight.Done()
+		}()
+		done <- c.perform(opQuery)
+	}()
+
+	var result *pb.Response
+	select {
+	case result = <-done:
+	case <-ctx.Done():
+		err := ErrOperationTimedOut
+		if ctx.Err() == context.Canceled {
+			err = ErrOperationCancelled
+		}
+		result = errorResponse(opQuery.OpId, err)
+	}
+	result.OpId = opQuery.OpId
+	c.reply(result)
+}
+
+// cancelOperation cancels the in-flight operation opQuery.CancelOpId. The cancelled operation replies with ErrOperationCancelled.
+func (c *connection) cancelOperation(opQuery *pb.Operation) *pb.Response {
+	c.opsLock.Lock()
+	defer c.opsLock.Unlock()
+
+	result := errorResponse(opQuery.OpId, ErrUnknownOperation)
+	if cancel, ok := c.operations[opQuery.CancelOpId]; ok {
+		cancel()
+		result.Status = pb.Response_SUCCESS
+		result.Error = ""
+		result.Info = fmt.Sprintf("Cancelled operation %d", opQuery.CancelOpId)
+	}
+	return result
+}
+
+// cancelOperations cancels every in-flight operation, e.g. when the connection goes away
+func (c *connection) cancelOperations() {
+	c.opsLock.Lock()
+	defer c.opsLock.Unlock()
+
+	for _, cancel := range c.operations {
+		cancel()
+	}
+}
+
+// reply sends the response to an operation back to the client. Responses are never dropped: a client that doesn't
+// make room for one within writeWait is disconnected, so it sees the operation fail instead of waiting for it forever.
+func (c *connection) reply(result *pb.Response) {
+	// Operations start out failed with ErrUnsupportedOperation and only clear the message once they succeed
+	if result.Status == pb.Response_SUCCESS {
+		result.ErrorCode = pb.Response_NONE
+		result.ErrorDetails = nil
+	}
+	msg, err := proto.Marshal(result)
+	if err != nil {
+		logError(err, "Error while marshaling operation result")
+		return
+	}
+
+	timer := time.NewTimer(writeWait)
+	defer timer.Stop()
+	select {
+	case c.replies <- msg:
+	case <-c.done:
+	case <-timer.C:
+		hubMetrics.Add("repliesTimedOut", 1)
+		logIt(fmt.Sprintf("Disconnecting connection for key with fingerprint %s that didn't make room for the response to operation %d", c.fingerprint, result.OpId))
+		H.drop(c)
+	}
+}
+
+// perform runs the operation and returns its result
+func (c *connection) perform(opQuery *pb.Operation) *pb.Response {
+	projectOp := opQuery.GetProjectOp()
+	adminOp := opQuery.GetAdminOp()
+	vaultOp := opQuery.GetVaultOp()
+	messageOp := opQuery.GetMessageOp()
+	eventOp := opQuery.GetEventOp()
+	result := errorResponse(0, ErrUnsupportedOperation)
+
+	// Perform the operation requested in the message (possibly by spawning a goroutine)
+	if projectOp != nil {
+
+		core := &pb.ProjectOperationResponse{
+			Command: projectOp.Command,
 		}
+		result.ProjectOpResponse = core
+
+		switch projectOp.Command {
+		case pb.ProjectOperation_LIST:
+			projects, err := c.listProjects(projectOp)
+			if err != nil {
+				logError(err, "Error when listing projects")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "projects"
+				if len(projects) == 1 {
+					label = "project"
+				}
+				result.Info = fmt.Sprintf("Found %d %s", len(projects), label)
+				result.Error = ""
+				core.Projects = projects
+			}
+
+		case pb.ProjectOperation_CREATE:
+			project, err := c.createProject(projectOp)
+			if err != nil {
+				logError(err, "Error while creating project")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully created project with ID = %d", project.Id)
+				result.Error = ""
+				core.Project = project
+			}
+
+		case pb.ProjectOperation_UPDATE:
 
-		// Perform the operation requested in the message (possibly by spawning a goroutine)
-		if projectOp != nil {
+		case pb.ProjectOperation_DELETE:
 
-			core := &pb.ProjectOperationResponse{
-				Command: projectOp.Command,
+		case pb.ProjectOperation_ADD_MEMBER:
+			memberId, err := c.addMember(projectOp)
+			if err != nil {
+				logError(err, "Error while adding member to project")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully added %s (member ID = %d) to project with ID = %d", projectOp.MemberEmail, memberId, projectOp.ProjectId)
+				result.Error = ""
 			}
-			result.ProjectOpResponse = core
 
-			switch projectOp.Command {
-			case pb.ProjectOperation_LIST:
-				projects, err := c.listProjects(projectOp)
-				if err != nil {
-					logError(err, "Error when listing projects")
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
-				} else {
-					result.Status = pb.Response_SUCCESS
-					label := "projects"
-					if len(projects) == 1 {
-						label = "project"
-					}
-					result.Info = fmt.Sprintf("Found %d %s", len(projects), label)
-					result.Error = ""
-					core.Projects = projects
+		case pb.ProjectOperation_DELETE_MEMBER:
+			err := c.deleteMember(projectOp)
+			if err != nil {
+				logError(err, "Error while deleting member from project")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully deleted member with ID = %d from project with ID = %d", projectOp.MemberId, projectOp.ProjectId)
+				result.Error = ""
+			}
+
+		case pb.ProjectOperation_LIST_CREDENTIALS:
+			creds, err := c.listCredentials(projectOp)
+			if err != nil {
+				logError(err, "Error while listing project credentials")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "credentials"
+				if len(creds) == 1 {
+					label = "credential"
 				}
+				result.Info = fmt.Sprintf("Found %d %s for project with ID = %d", len(creds), label, projectOp.ProjectId)
+				result.Error = ""
+				core.Credentials = creds
+			}
+
+		case pb.ProjectOperation_GET_CREDENTIAL:
+			cred, err := c.getCredential(projectOp)
+			if err != nil {
+				logError(err, "Error while getting a credential")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.Credential = cred
+			}
+
+		case pb.ProjectOperation_ADD_CREDENTIAL:
+			cred, err := c.setCredential(projectOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while setting credential with key '%s'", projectOp.Key))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully set credential with key '%s'", projectOp.Key)
+				result.Error = ""
+				core.Credential = cred
+			}
 
-			case pb.ProjectOperation_CREATE:
-				project, err := c.createProject(projectOp)
-				if err != nil {
-					logError(err, "Error while creating project")
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
+		case pb.ProjectOperation_UPLOAD_CREDENTIAL_CHUNK:
+			cred, done, err := c.uploadCredentialChunk(projectOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while uploading credential with key '%s'", projectOp.Key))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				if done {
+					result.Info = fmt.Sprintf("Successfully set credential with key '%s'", projectOp.Key)
 				} else {
-					result.Status = pb.Response_SUCCESS
-					result.Info = fmt.Sprintf("Successfully created project with ID = %d", project.Id)
-					result.Error = ""
-					core.Project = project
+					result.Info = fmt.Sprintf("Received %d bytes of credential with key '%s'", cred.Size, projectOp.Key)
 				}
+				result.Error = ""
+				core.Credential = cred
+			}
 
-			case pb.ProjectOperation_UPDATE:
+		case pb.ProjectOperation_GET_CREDENTIAL_CHUNK:
+			cred, err := c.getCredentialChunk(projectOp)
+			if err != nil {
+				logError(err, "Error while getting a credential chunk")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.Credential = cred
+			}
+
+		case pb.ProjectOperation_DELETE_CREDENTIAL:
+			err := c.deleteCredential(projectOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while deleting credential with key '%s'", projectOp.Key))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully deleted credential with key '%s'", projectOp.Key)
+				result.Error = ""
+			}
 
-			case pb.ProjectOperation_DELETE:
+		case pb.ProjectOperation_CREATE_SERVICE_ACCOUNT:
+			memberId, err := c.createServiceAccount(projectOp)
+			if err != nil {
+				logError(err, "Error while creating service account")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully created service account (member ID = %d) with read access to project with ID = %d", memberId, projectOp.ProjectId)
+				result.Error = ""
+				core.MemberId = memberId
+			}
 
-			case pb.ProjectOperation_ADD_MEMBER:
-				memberId, err := c.addMember(projectOp)
-				if err != nil {
-					logError(err, "Error while adding member to project")
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
-				} else {
-					result.Status = pb.Response_SUCCESS
-					result.Info = fmt.Sprintf("Successfully added %s (member ID = %d) to project with ID = %d", projectOp.MemberEmail, memberId, projectOp.ProjectId)
-					result.Error = ""
+		case pb.ProjectOperation_SERVICE_ACCOUNT_USAGE:
+			usage, err := c.serviceAccountUsage(projectOp)
+			if err != nil {
+				logError(err, "Error while listing service account usage")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "records"
+				if len(usage) == 1 {
+					label = "record"
 				}
+				result.Info = fmt.Sprintf("Found %d service account usage %s for project with ID = %d", len(usage), label, projectOp.ProjectId)
+				result.Error = ""
+				core.Usage = usage
+			}
 
-			case pb.ProjectOperation_DELETE_MEMBER:
-				err := c.deleteMember(projectOp)
-				if err != nil {
-					logError(err, "Error while deleting member from project")
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
-				} else {
-					result.Status = pb.Response_SUCCESS
-					result.Info = fmt.Sprintf("Successfully deleted member with ID = %d from project with ID = %d", projectOp.MemberId, projectOp.ProjectId)
-					result.Error = ""
+		case pb.ProjectOperation_SUBSCRIBE:
+			projects, err := c.subscribe(projectOp)
+			if err != nil {
+				logError(err, "Error while subscribing to project events")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "projects"
+				if len(projects) == 1 {
+					label = "project"
 				}
+				result.Info = fmt.Sprintf("Subscribed to events for %d %s", len(projects), label)
+				result.Error = ""
+				core.Projects = projects
+			}
+		}
+	}
 
-			case pb.ProjectOperation_LIST_CREDENTIALS:
-				creds, err := c.listCredentials(projectOp)
-				if err != nil {
-					logError(err, "Error while listing project credentials")
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
-				} else {
-					result.Status = pb.Response_SUCCESS
-					label := "credentials"
-					if len(creds) == 1 {
-						label = "credential"
-					}
-					result.Info = fmt.Sprintf("Found %d %s for project with ID = %d", len(creds), label, projectOp.ProjectId)
-					result.Error = ""
-					core.Credentials = creds
+	if adminOp != nil {
+
+		core := &pb.AdminOperationResponse{
+			Command: adminOp.Command,
+		}
+		result.AdminOpResponse = core
+
+		switch adminOp.Command {
+		case pb.AdminOperation_LIST_USERS:
+			users, err := c.listUsers(adminOp)
+			if err != nil {
+				logError(err, "Error when listing users")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "users"
+				if len(users) == 1 {
+					label = "user"
 				}
+				result.Info = fmt.Sprintf("Found %d %s", len(users), label)
+				result.Error = ""
+				core.Users = users
+			}
 
-			case pb.ProjectOperation_GET_CREDENTIAL:
-				cred, err := c.getCredential(projectOp)
-				if err != nil {
-					logError(err, "Error while getting a credential")
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
-				} else {
-					result.Status = pb.Response_SUCCESS
-					result.Info = ""
-					result.Error = ""
-					core.Credential = cred
+		case pb.AdminOperation_SUSPEND_USER:
+			err := c.runAdminAction(adminOp, int(adminOp.UserId), suspendUser)
+			if err != nil {
+				logError(err, "Error while suspending user")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully suspended user with ID = %d", adminOp.UserId)
+				result.Error = ""
+			}
+
+		case pb.AdminOperation_REACTIVATE_USER:
+			err := c.runAdminAction(adminOp, int(adminOp.UserId), reactivateUser)
+			if err != nil {
+				logError(err, "Error while reactivating user")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully reactivated user with ID = %d", adminOp.UserId)
+				result.Error = ""
+			}
+
+		case pb.AdminOperation_DELETE_USER:
+			err := c.runAdminAction(adminOp, int(adminOp.UserId), deleteUser)
+			if err != nil {
+				logError(err, "Error while deleting user")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully deleted user with ID = %d", adminOp.UserId)
+				result.Error = ""
+			}
+
+		case pb.AdminOperation_OFFBOARD_USER:
+			creds, err := c.offboardUser(adminOp)
+			if err != nil {
+				logError(err, "Error while offboarding user")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "credentials"
+				if len(creds) == 1 {
+					label = "credential"
 				}
+				result.Info = fmt.Sprintf("Successfully offboarded user with ID = %d. They could decrypt %d %s.", adminOp.UserId, len(creds), label)
+				result.Error = ""
+				core.ExposedCredentials = creds
+			}
 
-			case pb.ProjectOperation_ADD_CREDENTIAL:
-				cred, err := c.setCredential(projectOp)
-				if err != nil {
-					logError(err, fmt.Sprintf("Error while setting credential with key '%s'", projectOp.Key))
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
-				} else {
-					result.Status = pb.Response_SUCCESS
-					result.Info = fmt.Sprintf("Successfully set credential with key '%s'", projectOp.Key)
-					result.Error = ""
-					core.Credential = cred
+		case pb.AdminOperation_DELETE_KEY:
+			err := c.runAdminAction(adminOp, int(adminOp.KeyId), deleteKey)
+			if err != nil {
+				logError(err, "Error while deleting key")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully deleted key with ID = %d", adminOp.KeyId)
+				result.Error = ""
+			}
+		}
+	}
+
+	if vaultOp != nil {
+
+		core := &pb.VaultOperationResponse{
+			Command: vaultOp.Command,
+		}
+		result.VaultOpResponse = core
+
+		switch vaultOp.Command {
+		case pb.VaultOperation_LIST:
+			creds, err := c.listVaultCredentials(vaultOp)
+			if err != nil {
+				logError(err, "Error while listing vault credentials")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "credentials"
+				if len(creds) == 1 {
+					label = "credential"
 				}
+				result.Info = fmt.Sprintf("Found %d %s in your vault", len(creds), label)
+				result.Error = ""
+				core.Credentials = creds
+			}
+
+		case pb.VaultOperation_GET:
+			cred, err := c.getVaultCredential(vaultOp)
+			if err != nil {
+				logError(err, "Error while getting a vault credential")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.Credential = cred
+			}
+
+		case pb.VaultOperation_SET:
+			err := c.setVaultCredential(vaultOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while setting vault credential with key '%s'", vaultOp.Key))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully set vault credential with key '%s'", vaultOp.Key)
+				result.Error = ""
+			}
 
-			case pb.ProjectOperation_DELETE_CREDENTIAL:
-				err := c.deleteCredential(projectOp)
-				if err != nil {
-					logError(err, fmt.Sprintf("Error while deleting credential with key '%s'", projectOp.Key))
-					result.Status = pb.Response_ERROR
-					result.Error = err.Error()
+		case pb.VaultOperation_UPLOAD_CHUNK:
+			cred, done, err := c.uploadVaultChunk(vaultOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while uploading vault credential with key '%s'", vaultOp.Key))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				if done {
+					result.Info = fmt.Sprintf("Successfully set vault credential with key '%s'", vaultOp.Key)
 				} else {
-					result.Status = pb.Response_SUCCESS
-					result.Info = fmt.Sprintf("Successfully deleted credential with key '%s'", projectOp.Key)
-					result.Error = ""
+					result.Info = fmt.Sprintf("Received %d bytes of vault credential with key '%s'", cred.Size, vaultOp.Key)
+				}
+				result.Error = ""
+				core.Credential = cred
+			}
+
+		case pb.VaultOperation_GET_CHUNK:
+			cred, err := c.getVaultChunk(vaultOp)
+			if err != nil {
+				logError(err, "Error while getting a vault credential chunk")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.Credential = cred
+			}
+
+		case pb.VaultOperation_DELETE:
+			err := c.deleteVaultCredential(vaultOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while deleting vault credential with key '%s'", vaultOp.Key))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully deleted vault credential with key '%s'", vaultOp.Key)
+				result.Error = ""
+			}
+		}
+	}
+
+	if messageOp != nil {
+
+		core := &pb.MessageOperationResponse{
+			Command: messageOp.Command,
+		}
+		result.MessageOpResponse = core
+
+		switch messageOp.Command {
+		case pb.MessageOperation_LIST:
+			messages, total, filter, err := c.listMessages(messageOp)
+			if err != nil {
+				logError(err, "Error while listing messages")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "messages"
+				if total == 1 {
+					label = "message"
+				}
+				result.Info = fmt.Sprintf("Found %d %s", total, label)
+				result.Error = ""
+				core.Messages = messages
+				core.Total = int32(total)
+				core.Page = int32(filter.Page)
+				core.PerPage = int32(filter.PerPage)
+			}
+
+		case pb.MessageOperation_MARK_READ:
+			err := c.updateMessage(messageOp, markMessageRead)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while marking message %d as read", messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully marked message %d as read", messageOp.MessageId)
+				result.Error = ""
+			}
+
+		case pb.MessageOperation_MARK_UNREAD:
+			err := c.updateMessage(messageOp, markMessageUnread)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while marking message %d as unread", messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully marked message %d as unread", messageOp.MessageId)
+				result.Error = ""
+			}
+
+		case pb.MessageOperation_SEND:
+			n, err := c.sendMessage(messageOp)
+			if err != nil {
+				logError(err, "Error while sending message")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "keys"
+				if n == 1 {
+					label = "key"
+				}
+				result.Info = fmt.Sprintf("Successfully sent message encrypted to %d %s", n, label)
+				result.Error = ""
+			}
+
+		case pb.MessageOperation_GET:
+			m, err := c.getMessage(messageOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while getting message %d", messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.Message = m
+			}
+
+		case pb.MessageOperation_REPLY:
+			n, err := c.replyToMessage(messageOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while replying to message %d", messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "keys"
+				if n == 1 {
+					label = "key"
+				}
+				result.Info = fmt.Sprintf("Successfully sent reply encrypted to %d %s", n, label)
+				result.Error = ""
+			}
+
+		case pb.MessageOperation_THREAD:
+			messages, err := c.messageThread(messageOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while getting thread of message %d", messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				label := "messages"
+				if len(messages) == 1 {
+					label = "message"
 				}
+				result.Info = fmt.Sprintf("Found %d %s in thread", len(messages), label)
+				result.Error = ""
+				core.Messages = messages
+				core.Total = int32(len(messages))
+			}
+
+		case pb.MessageOperation_GET_ATTACHMENT:
+			a, err := c.getAttachment(messageOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while getting attachment %d of message %d", messageOp.AttachmentId, messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.Attachment = a
+			}
+
+		case pb.MessageOperation_UPLOAD_ATTACHMENT_CHUNK:
+			a, err := c.uploadAttachmentChunk(messageOp)
+			if err != nil {
+				logError(err, "Error while uploading an attachment")
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Received %d bytes of attachment '%s'", a.Size, a.Filename)
+				result.Error = ""
+				core.Attachment = a
+			}
+
+		case pb.MessageOperation_DELETE:
+			err := c.updateMessage(messageOp, deleteMessage)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while deleting message %d", messageOp.MessageId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = fmt.Sprintf("Successfully deleted message %d", messageOp.MessageId)
+				result.Error = ""
+			}
+		}
+	}
+
+	if eventOp != nil {
+
+		core := &pb.EventOperationResponse{
+			Command: eventOp.Command,
+		}
+		result.EventOpResponse = core
+
+		switch eventOp.Command {
+		case pb.EventOperation_ACK:
+			lastEventId, err := c.ackEvents(eventOp)
+			if err != nil {
+				logError(err, fmt.Sprintf("Error while acknowledging event %d", eventOp.EventId))
+				setError(result, err)
+			} else {
+				result.Status = pb.Response_SUCCESS
+				result.Info = ""
+				result.Error = ""
+				core.LastEventId = int32(lastEventId)
 			}
 		}
+	}
+
+	return result
+}
 
-		// Send back the response by calling c.send
-		msg, err := proto.Marshal(result)
+// backlog reads the events the key has not acknowledged, oldest first, as many as fit in the send queue
+func (c *connection) backlog(dbMap crypto.DataMapper) (backlog, error) {
+	b := backlog{c: c}
+	k, err := crypto.FindKeyWithId(int(c.keyId), dbMap)
+	if err != nil {
+		return b, err
+	}
+	events, err := k.UndeliveredEvents(dbMap)
+	if err != nil {
+		return b, err
+	}
+	for _, e := range events {
+		// Anything left over is replayed the next time the key connects
+		if len(b.payloads) == sendQueueSize {
+			return b, nil
+		}
+		d := delivery{EventId: e.Id(), Text: e.Text()}
+		var payload []byte
+		if e.MessageId() == 0 {
+			payload, err = marshalEvent(newNotificationEvent(d))
+		} else {
+			d.Message, err = crypto.FindMessageForPublicKey(e.MessageId(), k.Id(), dbMap)
+			// The message was deleted or has expired since the event was queued
+			if err == crypto.MessageNotFoundError {
+				continue
+			} else if err != nil {
+				return b, err
+			}
+			payload, err = marshalEvent(newMessageEvent(d))
+		}
 		if err != nil {
-			logError(err, "Error while marshaling operation result")
-			continue
+			return b, err
 		}
-		c.send <- msg
+		b.payloads = append(b.payloads, payload)
+		b.lastEventId = int32(e.Id())
+	}
+	b.complete = true
+	return b, nil
+}
+
+func (c *connection) ackEvents(op *pb.EventOperation) (int, error) {
+	if !c.isCLI || op.EventId <= 0 {
+		return 0, ErrInvalidArgsForEventOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return 0, err
+	}
+	defer dbMap.Close()
+
+	k, err := crypto.FindKeyWithId(int(c.keyId), dbMap)
+	if err != nil {
+		return 0, err
+	}
+	if err := k.AckEvents(int(op.EventId), dbMap); err != nil {
+		return 0, err
 	}
+	return k.LastEventId(), nil
 }
 
 // write writes a message with the given message type and payload.
@@ -369,6 +963,12 @@ func (c *connection) write(mt int, payload []byte) error {
 	return c.ws.WriteMessage(mt, payload)
 }
 
+// goAway tells the client why it's being disconnected so it can reconnect once the server is back
+func (c *connection) goAway() {
+	msg := websocket.FormatCloseMessage(websocket.CloseGoingAway, "Server is shutting down")
+	c.ws.WriteControl(websocket.CloseMessage, msg, time.Now().Add(writeWait))
+}
+
 // writePump pumps messages from the hub to the websocket connection.
 func (c *connection) writePump() {
 	ticker := time.NewTicker(pingPeriod)
@@ -379,17 +979,33 @@ func (c *connection) writePump() {
 		c.ws.Close()
 	}()
 
+	messageType := websocket.TextMessage
+	if c.isCLI {
+		messageType = websocket.BinaryMessage
+	}
+	hubDone := H.done
 	for {
+		// Responses go out ahead of pushes
+		select {
+		case message := <-c.replies:
+			if err := c.write(messageType, message); err != nil {
+				return
+			}
+			continue
+		default:
+		}
+
 		select {
+		case message := <-c.replies:
+			if err := c.write(messageType, message); err != nil {
+				return
+			}
+
 		case message, ok := <-c.send:
 			if !ok {
 				c.write(websocket.CloseMessage, []byte{})
 				return
 			}
-			messageType := websocket.TextMessage
-			if c.isCLI {
-				messageType = websocket.BinaryMessage
-			}
 			if err := c.write(messageType, message); err != nil {
 				return
 			}
@@ -398,6 +1014,18 @@ func (c *connection) writePump() {
 			if err := c.write(websocket.PingMessage, []byte{}); err != nil {
 				return
 			}
+
+		case <-hubDone:
+			// The hub closed the connections it knew about on its way out. CLI connections that never said HELLO
+			// weren't among them.
+			hubDone = nil
+			c.lock.Lock()
+			closed := c.closed
+			c.lock.Unlock()
+			if !closed {
+				c.goAway()
+				return
+			}
 		}
 	}
 }
@@ -433,6 +1061,9 @@ func (c *connection) createProject(op *pb.ProjectOperation) (*pb.Project, error)
 	if !c.isCLI {
 		return nil, ErrInvalidArgsForProjectOp
 	}
+	if c.isServiceAccount {
+		return nil, ErrNoAccess
+	}
 	name := strings.TrimSpace(op.Name)
 	environ := strings.TrimSpace(op.Environment)
 	// Make sure we have all the requirements to perform the operation
@@ -505,12 +1136,21 @@ func (c *connection) addMember(op *pb.ProjectOperation) (int32, error) {
 		return 0, err
 	}
 
+	// Adding an existing member changes their access level
+	prev, _ := crypto.FindProjectMemberWithUserId(u.Id(), p.Id(), dbMap)
+
 	// Add a member to the project by granting current userId admin access
 	m, err := p.AddMember(int(u.Id()), accessLevel, dbMap)
 	if err != nil {
 		return 0, err
 	}
 
+	if prev == nil {
+		publishMemberEvent(p, pb.ProjectEvent_MEMBER_ADDED, m, dbMap)
+	} else if prev.AccessLevel() != m.AccessLevel() {
+		publishMemberEvent(p, pb.ProjectEvent_MEMBER_ROLE_CHANGED, m, dbMap)
+	}
+
 	// Return the new project
 	return int32(m.Id()), nil
 }
@@ -541,7 +1181,12 @@ func (c *connection) deleteMember(op *pb.ProjectOperation) error {
 		return err
 	}
 
-	// Make sure the user is an admin of the project
+	// The member has to belong to the project the client named, if it named one
+	if op.ProjectId != 0 && int(op.ProjectId) != m.ProjectId() {
+		return ErrInvalidArgsForProjectOp
+	}
+
+	// Make sure the user is an admin of the project
 	p, err := crypto.FindProjectWithId(m.ProjectId(), dbMap)
 	if err != nil {
 		return ErrNoAccess
@@ -552,8 +1197,11 @@ func (c *connection) deleteMember(op *pb.ProjectOperation) error {
 		return ErrNoAccess
 	}
 
-	// Return the new project
-	return m.Delete(dbMap)
+	if err = m.Delete(dbMap); err != nil {
+		return err
+	}
+	publishMemberEvent(p, pb.ProjectEvent_MEMBER_REMOVED, m, dbMap)
+	return nil
 }
 
 func (c *connection) getCredential(op *pb.ProjectOperation) (*pb.Credential, error) {
@@ -581,15 +1229,28 @@ func (c *connection) getCredential(op *pb.ProjectOperation) (*pb.Credential, err
 		return nil, err
 	}
 
+	if !p.HasMemberWithUserId(int(c.userId), dbMap) {
+		return nil, ErrNoAccess
+	}
+
 	pv, err := p.GetCredential(key, int(c.keyId), dbMap)
 	if err != nil {
 		return nil, err
 	}
 
+	// Reads by service accounts are recorded so admins can review them separately.
+	// Chunked downloads are recorded once, when the first chunk is read.
+	if c.isServiceAccount && op.Offset == 0 {
+		if err := crypto.RecordServiceAccountUsage(int(c.userId), p.Id(), pv.CredentialId(), int(c.keyId), dbMap); err != nil {
+			logError(err, "Error recording service account usage")
+		}
+	}
+
 	cred := pb.Credential{
 		Id:     int32(pv.CredentialId()),
 		Key:    key,
 		Cipher: string(pv.Cipher()),
+		Binary: pv.Binary(),
 	}
 
 	// Return the new project
@@ -619,6 +1280,10 @@ func (c *connection) listCredentials(op *pb.ProjectOperation) ([]*pb.Credential,
 		return nil, err
 	}
 
+	if !p.HasMemberWithUserId(int(c.userId), dbMap) {
+		return nil, ErrNoAccess
+	}
+
 	pcList, err := p.Credentials(dbMap)
 	if err != nil {
 		return nil, err
@@ -642,7 +1307,7 @@ func (c *connection) setCredential(op *pb.ProjectOperation) (*pb.Credential, err
 	// Validate important input
 	projectId := int(op.ProjectId)
 	key := strings.TrimSpace(op.Key)
-	value := op.Value
+	value := credentialValue(op.Value, op.Data)
 	// Make sure we have all the requirements to perform the operation
 	if projectId == 0 || key == "" || value == "" {
 		return nil, ErrInvalidArgsForCredentialOp
@@ -670,6 +1335,7 @@ func (c *connection) setCredential(op *pb.ProjectOperation) (*pb.Credential, err
 	if err != nil {
 		return nil, err
 	}
+	publishProjectEvent(p, &pb.ProjectEvent{Type: pb.ProjectEvent_CREDENTIAL_SET, Key: key}, dbMap)
 
 	cred := pb.Credential{
 		Id:  int32(pc.Id()),
@@ -680,6 +1346,65 @@ func (c *connection) setCredential(op *pb.ProjectOperation) (*pb.Credential, err
 	return &cred, nil
 }
 
+// checkProjectAdmin fails with ErrNoAccess unless the user is an admin of the project
+func (c *connection) checkProjectAdmin(projectId int) error {
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return err
+	}
+	defer dbMap.Close()
+
+	p, err := crypto.FindProjectWithId(projectId, dbMap)
+	if err != nil {
+		return err
+	}
+	if !p.HasAdminWithUserId(int(c.userId), dbMap) {
+		return ErrNoAccess
+	}
+	return nil
+}
+
+func (c *connection) uploadCredentialChunk(op *pb.ProjectOperation) (*pb.Credential, bool, error) {
+	if !c.isCLI {
+		return nil, false, ErrInvalidArgsForCredentialOp
+	}
+	key := strings.TrimSpace(op.Key)
+	if op.ProjectId == 0 || key == "" {
+		return nil, false, ErrInvalidArgsForCredentialOp
+	}
+	// Nothing is kept for projects the user can't set credentials in
+	if op.Offset == 0 {
+		if err := c.checkProjectAdmin(int(op.ProjectId)); err != nil {
+			return nil, false, err
+		}
+	}
+
+	value, received, err := c.appendChunk(fmt.Sprintf("project:%d:%s", op.ProjectId, key), op.Offset, op.Data, op.Final, crypto.MaxCredentialSize, crypto.CredentialTooLargeError)
+	if err != nil || !op.Final {
+		return &pb.Credential{Key: key, Size: received}, false, err
+	}
+
+	// The whole value has arrived so it can be stored like a single frame ADD_CREDENTIAL
+	cred, err := c.setCredential(&pb.ProjectOperation{
+		ProjectId: op.ProjectId,
+		Key:       key,
+		Data:      value,
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	cred.Size = received
+	return cred, true, nil
+}
+
+func (c *connection) getCredentialChunk(op *pb.ProjectOperation) (*pb.Credential, error) {
+	cred, err := c.getCredential(op)
+	if err != nil {
+		return nil, err
+	}
+	return credentialChunk(cred, op.Offset, op.ChunkSize)
+}
+
 func (c *connection) deleteCredential(op *pb.ProjectOperation) error {
 	if !c.isCLI {
 		return ErrInvalidArgsForCredentialOp
@@ -714,17 +1439,930 @@ func (c *connection) deleteCredential(op *pb.ProjectOperation) error {
 	if err != nil {
 		return err
 	}
+	publishProjectEvent(p, &pb.ProjectEvent{Type: pb.ProjectEvent_CREDENTIAL_DELETED, Key: key}, dbMap)
 	return nil
 }
 
-func newConnection(wsConn *websocket.Conn, uid userId, keyId publicKeyId, fpr fingerprint, isCLI bool) *connection {
-	return &connection{
-		lock:        &sync.Mutex{},
-		send:        make(chan []byte, 256),
-		ws:          wsConn,
-		userId:      uid,
-		keyId:       keyId,
-		fingerprint: fpr,
-		isCLI:       isCLI,
+func (c *connection) createServiceAccount(op *pb.ProjectOperation) (int32, error) {
+	if !c.isCLI {
+		return 0, ErrInvalidArgsForProjectOp
+	}
+
+	// Validate important input
+	projectId := int(op.ProjectId)
+	publicKey := strings.TrimSpace(op.Value)
+	// Make sure we have all the requirements to perform the operation
+	if projectId == 0 || publicKey == "" {
+		return 0, ErrInvalidArgsForProjectOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return 0, err
+	}
+	defer dbMap.Close()
+
+	p, err := crypto.FindProjectWithId(projectId, dbMap)
+	if err != nil {
+		return 0, err
+	}
+
+	// Assert that the current user has admin access to the project
+	if !p.HasAdminWithUserId(int(c.userId), dbMap) {
+		return 0, ErrNoAccess
+	}
+
+	// Import the key and activate it on behalf of the service account
+	_, u, err := crypto.ImportServiceAccount(publicKey, strings.TrimSpace(op.Name), int(c.userId), dbMap)
+	if err != nil {
+		return 0, err
+	}
+
+	// Service accounts are always read-only members
+	m, err := p.AddMember(u.Id(), crypto.ACCESS_LEVEL_READ, dbMap)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(m.Id()), nil
+}
+
+func (c *connection) serviceAccountUsage(op *pb.ProjectOperation) ([]*pb.ServiceAccountUsage, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForProjectOp
+	}
+	// Validate important input
+	projectId := int(op.ProjectId)
+	// Make sure we have all the requirements to perform the operation
+	if projectId == 0 {
+		return nil, ErrInvalidArgsForProjectOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	p, err := crypto.FindProjectWithId(projectId, dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assert that the current user has admin access to the project
+	if !p.HasAdminWithUserId(int(c.userId), dbMap) {
+		return nil, ErrNoAccess
+	}
+
+	usage, err := p.ServiceAccountUsage(dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*pb.ServiceAccountUsage
+	for _, u := range usage {
+		ret = append(ret, &pb.ServiceAccountUsage{
+			UserId:         int32(u.UserId()),
+			Email:          u.Email(),
+			Key:            u.Key(),
+			Count:          int32(u.Count()),
+			LastAccessedAt: u.LastAccessedAt().Unix(),
+		})
+	}
+	return ret, nil
+}
+
+// authenticateAdmin returns the user of the connection if they are a server administrator and the connection was opened
+// with an API token. The token and the key are checked on every operation, so revoking either one stops admin
+// operations on connections that are already open.
+func (c *connection) authenticateAdmin(dbMap crypto.DataMapper) (crypto.User, error) {
+	if c.apiToken == "" {
+		return nil, ErrAuthenticationRequired
+	}
+	t, err := crypto.FindAPIToken(c.apiToken, dbMap)
+	if err != nil {
+		return nil, err
+	}
+	if t.PublicKeyId() != int(c.keyId) {
+		return nil, crypto.APITokenNotFoundError
+	}
+	key, err := crypto.FindKeyWithId(int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	if !key.Active() || key.Expired() || key.Revoked() {
+		return nil, InactiveKeyError
+	}
+	admin, err := findAdmin(int(c.userId), dbMap)
+	if err != nil {
+		return nil, ErrNoAccess
+	}
+	return admin, nil
+}
+
+func (c *connection) listUsers(op *pb.AdminOperation) ([]*pb.User, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForAdminOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	if _, err := c.authenticateAdmin(dbMap); err != nil {
+		return nil, err
+	}
+
+	users, err := crypto.FindAllUsers(dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*pb.User
+	for _, u := range users {
+		keys, err := u.PublicKeys(dbMap)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, newPbUser(u, keys))
+	}
+	return ret, nil
+}
+
+func (c *connection) runAdminAction(op *pb.AdminOperation, id int, action func(crypto.User, int, crypto.DataMapper) error) error {
+	if !c.isCLI {
+		return ErrInvalidArgsForAdminOp
+	}
+	// Make sure we have all the requirements to perform the operation
+	if id == 0 {
+		return ErrInvalidArgsForAdminOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return err
+	}
+	defer dbMap.Close()
+
+	admin, err := c.authenticateAdmin(dbMap)
+	if err != nil {
+		return err
+	}
+
+	return action(admin, id, dbMap)
+}
+
+func (c *connection) offboardUser(op *pb.AdminOperation) ([]*pb.ExposedCredential, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForAdminOp
+	}
+	// Make sure we have all the requirements to perform the operation
+	if op.UserId == 0 {
+		return nil, ErrInvalidArgsForAdminOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	admin, err := c.authenticateAdmin(dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := offboardUser(admin, int(op.UserId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*pb.ExposedCredential
+	for _, ec := range report {
+		ret = append(ret, &pb.ExposedCredential{
+			ProjectId:   int32(ec.ProjectId()),
+			ProjectName: ec.ProjectName(),
+			Environment: ec.Environment(),
+			Key:         ec.Key(),
+		})
+	}
+	return ret, nil
+}
+
+func (c *connection) listVaultCredentials(op *pb.VaultOperation) ([]*pb.Credential, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForVaultOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	u, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	creds, err := u.VaultCredentials(int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*pb.Credential
+	for _, uc := range creds {
+		ret = append(ret, &pb.Credential{
+			Id:  int32(uc.Id()),
+			Key: uc.Key(),
+		})
+	}
+	return ret, nil
+}
+
+func (c *connection) getVaultCredential(op *pb.VaultOperation) (*pb.Credential, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForVaultOp
+	}
+	// Validate important input
+	key := strings.TrimSpace(op.Key)
+	// Make sure we have all the requirements to perform the operation
+	if key == "" {
+		return nil, ErrInvalidArgsForVaultOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	u, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	uc, err := u.GetVaultCredential(key, int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.Credential{
+		Id:     int32(uc.Id()),
+		Key:    uc.Key(),
+		Cipher: string(uc.Cipher()),
+		Binary: uc.Binary(),
+	}, nil
+}
+
+func (c *connection) setVaultCredential(op *pb.VaultOperation) error {
+	if !c.isCLI {
+		return ErrInvalidArgsForVaultOp
+	}
+	// Validate important input
+	key := strings.TrimSpace(op.Key)
+	value := credentialValue(op.Value, op.Data)
+	// Make sure we have all the requirements to perform the operation
+	if key == "" || value == "" {
+		return ErrInvalidArgsForVaultOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return err
+	}
+	defer dbMap.Close()
+
+	u, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		return err
+	}
+
+	return u.SetVaultCredential(key, value, dbMap)
+}
+
+func (c *connection) uploadVaultChunk(op *pb.VaultOperation) (*pb.Credential, bool, error) {
+	if !c.isCLI {
+		return nil, false, ErrInvalidArgsForVaultOp
+	}
+	key := strings.TrimSpace(op.Key)
+	if key == "" {
+		return nil, false, ErrInvalidArgsForVaultOp
+	}
+
+	value, received, err := c.appendChunk("vault:"+key, op.Offset, op.Data, op.Final, crypto.MaxCredentialSize, crypto.CredentialTooLargeError)
+	if err != nil || !op.Final {
+		return &pb.Credential{Key: key, Size: received}, false, err
+	}
+
+	if err := c.setVaultCredential(&pb.VaultOperation{Key: key, Data: value}); err != nil {
+		return nil, false, err
+	}
+	return &pb.Credential{Key: key, Size: received}, true, nil
+}
+
+func (c *connection) getVaultChunk(op *pb.VaultOperation) (*pb.Credential, error) {
+	cred, err := c.getVaultCredential(op)
+	if err != nil {
+		return nil, err
+	}
+	return credentialChunk(cred, op.Offset, op.ChunkSize)
+}
+
+func (c *connection) deleteVaultCredential(op *pb.VaultOperation) error {
+	if !c.isCLI {
+		return ErrInvalidArgsForVaultOp
+	}
+	// Validate important input
+	key := strings.TrimSpace(op.Key)
+	// Make sure we have all the requirements to perform the operation
+	if key == "" {
+		return ErrInvalidArgsForVaultOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return err
+	}
+	defer dbMap.Close()
+
+	u, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		return err
+	}
+
+	return u.RemoveVaultCredential(key, dbMap)
+}
+
+func (c *connection) listMessages(op *pb.MessageOperation) ([]*pb.Message, int, crypto.MessageFilter, error) {
+	filter := crypto.MessageFilter{
+		SenderEmail: strings.TrimSpace(op.Sender),
+		Subject:     strings.TrimSpace(op.Subject),
+		UnreadOnly:  op.UnreadOnly,
+		Page:        int(op.Page),
+		PerPage:     int(op.PerPage),
+	}
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 || filter.PerPage > crypto.MAX_MESSAGES_PER_PAGE {
+		filter.PerPage = crypto.DEFAULT_MESSAGES_PER_PAGE
+	}
+	if op.Since > 0 {
+		filter.Since = time.Unix(op.Since, 0)
+	}
+	if op.Until > 0 {
+		filter.Until = time.Unix(op.Until, 0)
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, 0, filter, err
+	}
+	defer dbMap.Close()
+
+	k, err := crypto.FindKeyWithId(int(c.keyId), dbMap)
+	if err != nil {
+		return nil, 0, filter, err
+	}
+
+	messages, total, err := k.FindMessages(filter, dbMap)
+	if err != nil {
+		return nil, 0, filter, err
+	}
+
+	var readerEmail string
+	var ret []*pb.Message
+	for _, m := range messages {
+		pm := newPbMessage(m)
+		ret = append(ret, pm)
+		if !m.ViewOnce() {
+			continue
+		}
+		if err = loadAttachmentCiphers(pm, m, dbMap); err != nil {
+			return nil, 0, filter, err
+		}
+		// View once messages are removed as soon as they have been fetched
+		if readerEmail == "" {
+			if u := k.User(dbMap); u != nil {
+				readerEmail = u.Email()
+			}
+		}
+		if err = consumeMessage(m, readerEmail, dbMap); err != nil {
+			return nil, 0, filter, err
+		}
+	}
+	return ret, total, filter, nil
+}
+
+func (c *connection) updateMessage(op *pb.MessageOperation, action func(crypto.EncryptedMessage, crypto.DataMapper) error) error {
+	// Make sure we have all the requirements to perform the operation
+	if op.MessageId == 0 {
+		return ErrInvalidArgsForMessageOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return err
+	}
+	defer dbMap.Close()
+
+	// Only messages encrypted to the key of this connection can be changed
+	m, err := crypto.FindMessageForPublicKey(int(op.MessageId), int(c.keyId), dbMap)
+	if err != nil {
+		return err
+	}
+	return action(m, dbMap)
+}
+
+func (c *connection) sendMessage(op *pb.MessageOperation) (int, error) {
+	if !c.isCLI {
+		return 0, ErrInvalidArgsForMessageOp
+	}
+	// Service accounts only read
+	if c.isServiceAccount {
+		return 0, ErrNoAccess
+	}
+	// Validate important input
+	subject := strings.TrimSpace(op.Subject)
+	// Make sure we have all the requirements to perform the operation
+	if op.Body == "" || (len(op.To) == 0 && op.ProjectId == 0) || op.Ttl < 0 {
+		return 0, ErrInvalidArgsForMessageOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return 0, err
+	}
+	defer dbMap.Close()
+
+	sender, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		return 0, err
+	}
+
+	var toProject crypto.Project
+	var toUsers []crypto.User
+	if op.ProjectId > 0 {
+		toProject, err = crypto.FindProjectWithId(int(op.ProjectId), dbMap)
+		if err != nil {
+			return 0, err
+		}
+		// Only members can message a project
+		if toProject.Id() == 0 || !toProject.HasMemberWithUserId(sender.Id(), dbMap) {
+			return 0, ErrNoAccess
+		}
+	} else {
+		for _, email := range op.To {
+			email = strings.TrimSpace(email)
+			u, err := crypto.FindUserWithEmail(email, dbMap)
+			if err != nil {
+				return 0, err
+			}
+			toUsers = append(toUsers, u)
+		}
+	}
+
+	opts := c.messageOptions(op)
+	encryptedMessages, err := sendMessage(sender, toProject, toUsers, op.Body, subject, opts, dbMap)
+	if err != nil {
+		return 0, err
+	}
+	return len(encryptedMessages), nil
+}
+
+func (c *connection) getMessage(op *pb.MessageOperation) (*pb.Message, error) {
+	// Make sure we have all the requirements to perform the operation
+	if op.MessageId == 0 {
+		return nil, ErrInvalidArgsForMessageOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	m, err := crypto.FindMessageForPublicKey(int(op.MessageId), int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	ret := newPbMessage(m)
+
+	// View once messages are removed as soon as they have been fetched. Everything else is marked as read.
+	if m.ViewOnce() {
+		if err = loadAttachmentCiphers(ret, m, dbMap); err != nil {
+			return nil, err
+		}
+		u, err := crypto.FindUserWithId(int(c.userId), dbMap)
+		if err != nil {
+			return nil, err
+		}
+		err = consumeMessage(m, u.Email(), dbMap)
+	} else {
+		err = markMessageRead(m, dbMap)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+func (c *connection) getAttachment(op *pb.MessageOperation) (*pb.Attachment, error) {
+	// Make sure we have all the requirements to perform the operation
+	if op.MessageId == 0 || op.AttachmentId == 0 {
+		return nil, ErrInvalidArgsForMessageOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	m, err := crypto.FindMessageForPublicKey(int(op.MessageId), int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	// The attachments of view once messages come with the message itself
+	if m.ViewOnce() {
+		return nil, ErrInvalidArgsForMessageOp
+	}
+
+	a, err := crypto.FindAttachmentForPublicKey(int(op.AttachmentId), int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+	if a.MessageId() != m.Id() {
+		return nil, crypto.AttachmentNotFoundError
+	}
+	ret := newPbAttachment(a)
+	ret.Cipher = string(a.Cipher())
+	return ret, nil
+}
+
+func (c *connection) replyToMessage(op *pb.MessageOperation) (int, error) {
+	if !c.isCLI {
+		return 0, ErrInvalidArgsForMessageOp
+	}
+	// Service accounts only read
+	if c.isServiceAccount {
+		return 0, ErrNoAccess
+	}
+	// Validate important input
+	subject := strings.TrimSpace(op.Subject)
+	// Make sure we have all the requirements to perform the operation
+	if op.MessageId == 0 || op.Body == "" || op.Ttl < 0 {
+		return 0, ErrInvalidArgsForMessageOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return 0, err
+	}
+	defer dbMap.Close()
+
+	// Only messages encrypted to the key of this connection can be replied to
+	m, err := crypto.FindMessageForPublicKey(int(op.MessageId), int(c.keyId), dbMap)
+	if err != nil {
+		return 0, err
+	}
+
+	sender, err := crypto.FindUserWithId(int(c.userId), dbMap)
+	if err != nil {
+		return 0, err
+	}
+
+	opts := c.messageOptions(op)
+	encryptedMessages, err := replyToMessage(m, sender, op.Body, subject, opts, dbMap)
+	if err != nil {
+		return 0, err
+	}
+	return len(encryptedMessages), nil
+}
+
+func (c *connection) messageThread(op *pb.MessageOperation) ([]*pb.Message, error) {
+	// Make sure we have all the requirements to perform the operation
+	if op.MessageId == 0 {
+		return nil, ErrInvalidArgsForMessageOp
+	}
+
+	// Get a mapper
+	dbMap, err := crypto.NewDataMapper()
+	if err != nil {
+		return nil, err
+	}
+	defer dbMap.Close()
+
+	m, err := crypto.FindMessageForPublicKey(int(op.MessageId), int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	k, err := crypto.FindKeyWithId(int(c.keyId), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	messages, err := k.Thread(m.ThreadId(), dbMap)
+	if err != nil {
+		return nil, err
+	}
+
+	var ret []*pb.Message
+	for _, tm := range messages {
+		pm := newPbMessage(tm)
+		if tm.PublicKeyId() != k.Id() {
+			pm.Sent = true
+			pm.Cipher = ""
+		}
+		ret = append(ret, pm)
+	}
+	return ret, nil
+}
+
+func newPbMessage(m crypto.EncryptedMessage) *pb.Message {
+	ret := &pb.Message{
+		Id:               int32(m.Id()),
+		Subject:          m.Subject(),
+		Cipher:           string(m.Cipher()),
+		Read:             m.IsRead(),
+		ViewOnce:         m.ViewOnce(),
+		SubjectEncrypted: m.SubjectEncrypted(),
+		ParentId:         int32(m.ParentId()),
+		ThreadId:         int32(m.ThreadId()),
+		CreatedAt:        m.CreatedAt().Unix(),
+	}
+	if !m.ExpiresAt().IsZero() {
+		ret.ExpiresAt = m.ExpiresAt().Unix()
+	}
+	if sender := m.Sender(); sender != nil {
+		ret.SenderName = sender.Name()
+		ret.SenderEmail = sender.Email()
+	}
+	for _, u := range m.Recipients() {
+		ret.Recipients = append(ret.Recipients, u.Email())
+	}
+	for _, a := range m.Attachments() {
+		ret.Attachments = append(ret.Attachments, newPbAttachment(a))
+	}
+	return ret
+}
+
+// credentialValue prefers the binary safe data of an operation over its string value
+func credentialValue(value string, data []byte) string {
+	if len(data) > 0 {
+		return string(data)
+	}
+	return value
+}
+
+// An upload in progress
+type upload struct {
+	buf       bytes.Buffer
+	updatedAt time.Time
+}
+
+// An attachment uploaded in chunks that no message was sent with yet
+type uploadedAttachment struct {
+	crypto.AttachmentFile
+	uploadedAt time.Time
+}
+
+// appendChunk adds a chunk to the upload for target. Chunks have to arrive in order, starting at offset 0.
+// The value is returned once the final chunk arrives, along with the number of bytes received. Uploads larger than limit fail with tooLarge.
+// Chunks that would take the connection past maxUploadSize fail with ErrUploadsTooLarge.
+func (c *connection) appendChunk(target string, offset int64, data []byte, final bool, limit int64, tooLarge error) ([]byte, int64, error) {
+	c.uploadsLock.Lock()
+	defer c.uploadsLock.Unlock()
+
+	now := time.Now()
+	c.expireUploads(now)
+
+	u, ok := c.uploads[target]
+	if offset == 0 {
+		u = &upload{}
+		c.uploads[target] = u
+	} else if !ok || int64(u.buf.Len()) != offset {
+		delete(c.uploads, target)
+		return nil, 0, ErrChunkOutOfOrder
+	}
+
+	if int64(u.buf.Len()+len(data)) > limit {
+		delete(c.uploads, target)
+		return nil, 0, tooLarge
+	}
+	if c.uploadedBytes()+int64(len(data)) > maxUploadSize {
+		delete(c.uploads, target)
+		return nil, 0, ErrUploadsTooLarge
+	}
+	u.buf.Write(data)
+	u.updatedAt = now
+
+	received := int64(u.buf.Len())
+	if !final {
+		return nil, received, nil
+	}
+	delete(c.uploads, target)
+	return u.buf.Bytes(), received, nil
+}
+
+// uploadedBytes is what the uploads and attachments of the connection hold. The caller holds uploadsLock.
+func (c *connection) uploadedBytes() int64 {
+	var n int64
+	for _, u := range c.uploads {
+		n += int64(u.buf.Len())
+	}
+	for _, a := range c.attachments {
+		n += int64(len(a.Data))
+	}
+	return n
+}
+
+// expireUploads drops the uploads and attachments left alone for uploadTTL. The caller holds uploadsLock.
+func (c *connection) expireUploads(now time.Time) {
+	for target, u := range c.uploads {
+		if now.Sub(u.updatedAt) > uploadTTL {
+			delete(c.uploads, target)
+		}
+	}
+	for filename, a := range c.attachments {
+		if now.Sub(a.uploadedAt) > uploadTTL {
+			delete(c.attachments, filename)
+		}
+	}
+}
+
+// credentialChunk cuts the chunk starting at offset out of the cipher of cred. The size of the whole cipher is returned along with it.
+func credentialChunk(cred *pb.Credential, offset int64, chunkSize int32) (*pb.Credential, error) {
+	size := int64(len(cred.Cipher))
+	if offset < 0 || offset > size {
+		return nil, ErrInvalidArgsForCredentialOp
+	}
+	n := int64(chunkSize)
+	if n <= 0 || n > credentialChunkSize {
+		n = credentialChunkSize
+	}
+	end := offset + n
+	if end > size {
+		end = size
+	}
+	cred.Cipher = cred.Cipher[offset:end]
+	cred.Offset = offset
+	cred.Size = size
+	return cred, nil
+}
+
+func newPbAttachment(a crypto.MessageAttachment) *pb.Attachment {
+	return &pb.Attachment{
+		Id:          int32(a.Id()),
+		Filename:    a.Filename(),
+		ContentType: a.ContentType(),
+		Size:        a.Size(),
+	}
+}
+
+// loadAttachmentCiphers fills in the attachment ciphers of a view once message before it is consumed
+func loadAttachmentCiphers(pm *pb.Message, m crypto.EncryptedMessage, dbMap crypto.DataMapper) error {
+	for _, pa := range pm.Attachments {
+		a, err := crypto.FindAttachmentForPublicKey(int(pa.Id), m.PublicKeyId(), dbMap)
+		if err != nil {
+			return err
+		}
+		pa.Cipher = string(a.Cipher())
+	}
+	return nil
+}
+
+// uploadAttachmentChunk adds a chunk to the single attachment of op. Once the final chunk arrives the file is kept
+// until a SEND or REPLY names it without data.
+func (c *connection) uploadAttachmentChunk(op *pb.MessageOperation) (*pb.Attachment, error) {
+	if !c.isCLI {
+		return nil, ErrInvalidArgsForMessageOp
+	}
+	// Service accounts only read
+	if c.isServiceAccount {
+		return nil, ErrNoAccess
+	}
+	if len(op.Attachments) != 1 || op.Attachments[0].Filename == "" {
+		return nil, ErrInvalidArgsForMessageOp
+	}
+	a := op.Attachments[0]
+	filename := filepath.Base(a.Filename)
+	data, received, err := c.appendChunk("attachment:"+filename, op.Offset, a.Data, op.Final, crypto.MaxAttachmentSize, crypto.AttachmentTooLargeError)
+	if err != nil {
+		return nil, err
+	}
+	if op.Final {
+		c.uploadsLock.Lock()
+		c.attachments[filename] = &uploadedAttachment{
+			AttachmentFile: crypto.AttachmentFile{
+				Filename:    filename,
+				ContentType: a.ContentType,
+				Data:        data,
+			},
+			uploadedAt: time.Now(),
+		}
+		c.uploadsLock.Unlock()
+	}
+	return &pb.Attachment{
+		Filename:    filename,
+		ContentType: a.ContentType,
+		Size:        received,
+	}, nil
+}
+
+// messageOptions reads the time to live, view once, encrypt subject and attachments of a SEND or REPLY.
+// Attachments without data are the ones uploaded in chunks, they are only sent once.
+func (c *connection) messageOptions(op *pb.MessageOperation) crypto.MessageOptions {
+	opts := crypto.MessageOptions{
+		TTL:            time.Duration(op.Ttl) * time.Second,
+		ViewOnce:       op.ViewOnce,
+		EncryptSubject: op.EncryptSubject,
+	}
+
+	c.uploadsLock.Lock()
+	defer c.uploadsLock.Unlock()
+	for _, a := range op.Attachments {
+		f := crypto.AttachmentFile{
+			Filename:    filepath.Base(a.Filename),
+			ContentType: a.ContentType,
+			Data:        a.Data,
+		}
+		if uploaded, ok := c.attachments[f.Filename]; ok && len(f.Data) == 0 {
+			f = uploaded.AttachmentFile
+			delete(c.attachments, f.Filename)
+		}
+		opts.Attachments = append(opts.Attachments, f)
+	}
+	return opts
+}
+
+func newPbUser(u crypto.User, keys []crypto.PublicKey) *pb.User {
+	ret := &pb.User{
+		Id:        int32(u.Id()),
+		Name:      u.Name(),
+		Email:     u.Email(),
+		UserType:  u.UserType(),
+		Admin:     u.IsAdmin(),
+		Suspended: u.IsSuspended(),
+	}
+	if u.IsSuspended() {
+		ret.SuspendedAt = u.SuspendedAt().Unix()
+	}
+	for _, k := range keys {
+		pk := &pb.PublicKey{
+			Id:          int32(k.Id()),
+			Fingerprint: k.Fingerprint(),
+			Active:      k.Active(),
+		}
+		if k.Active() {
+			pk.ActivatedAt = k.ActivatedAt().Unix()
+		}
+		if !k.ExpiresAt().IsZero() {
+			pk.ExpiresAt = k.ExpiresAt().Unix()
+		}
+		ret.Keys = append(ret.Keys, pk)
+	}
+	return ret
+}
+
+func newConnection(wsConn *websocket.Conn, uid userId, keyId publicKeyId, fpr fingerprint, isCLI, isServiceAccount bool) *connection {
+	c := &connection{
+		lock:             &sync.Mutex{},
+		send:             make(chan []byte, sendQueueSize),
+		replies:          make(chan []byte, maxConcurrentOps),
+		done:             make(chan struct{}),
+		ws:               wsConn,
+		userId:           uid,
+		keyId:            keyId,
+		fingerprint:      fpr,
+		isCLI:            isCLI,
+		isServiceAccount: isServiceAccount,
+		uploads:          make(map[string]*upload),
+		attachments:      make(map[string]*uploadedAttachment),
+		slots:            make(chan struct{}, maxConcurrentOps),
+		operations:       make(map[int32]context.CancelFunc),
+	}
+	if !isCLI {
+		c.overflow = overflowDrop
 	}
+	return c
 }